@@ -0,0 +1,101 @@
+// Package account manages player accounts, their credentials, and login sessions.
+package account
+
+import (
+	"errors"
+	"regexp"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/store"
+)
+
+// Constants
+const (
+	// Shortest password allowed for an account
+	minPasswordLength = 8
+
+	// Longest password allowed for an account. bcrypt ignores anything past 72 bytes.
+	maxPasswordLength = 72
+)
+
+// Errors
+var (
+	// ErrInvalidName is returned when an account name is not allowed
+	ErrInvalidName = errors.New("account names must be 3 to 16 letters, numbers or underscores")
+
+	// ErrInvalidPassword is returned when a password is not allowed
+	ErrInvalidPassword = errors.New("passwords must be 8 to 72 characters")
+
+	// ErrNameTaken is returned when registering an account name that already exists
+	ErrNameTaken = errors.New("account name is already taken")
+
+	// ErrInvalidCredentials is returned when a login name and password do not match an account
+	ErrInvalidCredentials = errors.New("invalid account name or password")
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_]{3,16}$`)
+
+// dummyHash is checked against when logging in to an account that doesn't exist or has no password,
+// so those logins take as long as any other and don't give away which account names exist
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not anyone's password"), bcrypt.DefaultCost)
+
+// Service registers accounts, and logs them in to sessions
+type Service struct {
+	store    store.AccountRepository
+	sessions *Sessions
+}
+
+// NewService creates an account service backed by an account repository
+func NewService(accounts store.AccountRepository) *Service {
+	return &Service{
+		store:    accounts,
+		sessions: NewSessions(sessionLifetime),
+	}
+}
+
+// Register creates a new account with a salted hash of its password
+func (s *Service) Register(name, password string) (*store.Account, error) {
+	if !validName.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return nil, ErrInvalidPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.store.CreateAccount(name, hash)
+	if err == store.ErrConflict {
+		return nil, ErrNameTaken
+	}
+
+	return account, err
+}
+
+// Login checks an account's password, and starts a new session for it.
+// Returns the session token.
+func (s *Service) Login(name, password string) (string, error) {
+	account, err := s.store.GetAccountByName(name)
+	if err != nil && err != store.ErrNotFound {
+		return "", err
+	}
+
+	if err == store.ErrNotFound || len(account.PasswordHash) == 0 {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return "", ErrInvalidCredentials
+	}
+	if bcrypt.CompareHashAndPassword(account.PasswordHash, []byte(password)) != nil {
+		return "", ErrInvalidCredentials
+	}
+
+	return s.sessions.Start(account.Name)
+}
+
+// Authenticate returns the name of the account logged in to a session, or ErrInvalidSession
+func (s *Service) Authenticate(token string) (string, error) {
+	return s.sessions.Get(token)
+}
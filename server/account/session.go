@@ -0,0 +1,90 @@
+package account
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Constants
+const (
+	// How long a login session lasts
+	sessionLifetime = 24 * time.Hour
+
+	// Size of a session token, in random bytes
+	sessionTokenSize = 32
+)
+
+// ErrInvalidSession is returned when a session token is unknown or expired
+var ErrInvalidSession = errors.New("invalid or expired session")
+
+// session is a logged in account
+type session struct {
+	name    string
+	expires time.Time
+}
+
+// Sessions tracks login sessions by their token
+type Sessions struct {
+	mutex    sync.Mutex
+	lifetime time.Duration
+	sessions map[string]*session
+}
+
+// NewSessions creates an empty session tracker, where sessions last for a lifetime
+func NewSessions(lifetime time.Duration) *Sessions {
+	return &Sessions{
+		lifetime: lifetime,
+		sessions: make(map[string]*session),
+	}
+}
+
+// Start creates a new session for an account, returning its token
+func (s *Sessions) Start(name string) (string, error) {
+	bytes := make([]byte, sessionTokenSize)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(bytes)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.expire()
+	s.sessions[token] = &session{name: name, expires: time.Now().Add(s.lifetime)}
+
+	return token, nil
+}
+
+// Get returns the account name of a session, or ErrInvalidSession
+func (s *Sessions) Get(token string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, exists := s.sessions[token]
+	if !exists || time.Now().After(session.expires) {
+		return "", ErrInvalidSession
+	}
+
+	return session.name, nil
+}
+
+// End forgets a session, so its token can no longer be used
+func (s *Sessions) End(token string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.sessions, token)
+}
+
+// expire forgets all expired sessions. The mutex must be held.
+func (s *Sessions) expire() {
+	now := time.Now()
+	for token, session := range s.sessions {
+		if now.After(session.expires) {
+			delete(s.sessions, token)
+		}
+	}
+}
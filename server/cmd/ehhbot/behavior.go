@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/protobuf"
+)
+
+// directions are the directions walking bots pick between
+var directions = []string{"up", "down", "left", "right", "upleft", "upright", "downleft", "downright"}
+
+// Behavior scripts the inputs a bot sends
+type Behavior interface {
+	// Name identifies the behavior in reports
+	Name() string
+
+	// Period is how often the bot asks for its next input. Zero sends no inputs.
+	Period() time.Duration
+
+	// Next returns the bot's next input, numbered with sequence, or nil to send nothing this time
+	Next(random *rand.Rand, sequence uint32) *protobuf.Message
+}
+
+// newBehavior creates a behavior by name, sending inputs at up to rate per second
+func newBehavior(name string, rate float64) (Behavior, error) {
+	period := time.Duration(float64(time.Second) / rate)
+	if rate <= 0 {
+		period = 0
+	}
+
+	switch name {
+	case "walk":
+		return &walkBehavior{period: period}, nil
+	case "attack":
+		return &attackBehavior{period: period}, nil
+	case "idle":
+		return &idleBehavior{}, nil
+	default:
+		return nil, fmt.Errorf("unknown behavior %q; expected 'walk', 'attack' or 'idle'", name)
+	}
+}
+
+// walkBehavior wanders randomly, now and then stopping for a moment
+type walkBehavior struct {
+	period    time.Duration
+	direction string
+}
+
+func (b *walkBehavior) Name() string {
+	return "walk"
+}
+
+func (b *walkBehavior) Period() time.Duration {
+	return b.period
+}
+
+// Next keeps walking the same way most of the time, so bots cover some ground
+func (b *walkBehavior) Next(random *rand.Rand, sequence uint32) *protobuf.Message {
+	switch roll := random.Float64(); {
+	case b.direction == "" || roll < 0.2:
+		b.direction = directions[random.Intn(len(directions))]
+	case roll < 0.25:
+		b.direction = "stop"
+	}
+
+	return &protobuf.Message{Payload: &protobuf.Message_Move{Move: &protobuf.Move{Direction: b.direction, Sequence: sequence}}}
+}
+
+// attackBehavior attacks as fast as it is allowed to
+type attackBehavior struct {
+	period time.Duration
+}
+
+func (b *attackBehavior) Name() string {
+	return "attack"
+}
+
+func (b *attackBehavior) Period() time.Duration {
+	return b.period
+}
+
+func (b *attackBehavior) Next(random *rand.Rand, sequence uint32) *protobuf.Message {
+	return &protobuf.Message{Payload: &protobuf.Message_Attack{Attack: &protobuf.Attack{Sequence: sequence}}}
+}
+
+// idleBehavior stands still, only acknowledging snapshots
+type idleBehavior struct{}
+
+func (b *idleBehavior) Name() string {
+	return "idle"
+}
+
+func (b *idleBehavior) Period() time.Duration {
+	return 0
+}
+
+func (b *idleBehavior) Next(random *rand.Rand, sequence uint32) *protobuf.Message {
+	return nil
+}
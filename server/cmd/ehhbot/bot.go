@@ -0,0 +1,365 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/protobuf"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/gorilla/websocket"
+)
+
+// Constants
+const (
+	// Protocol version bots speak
+	protocolVersion = 1
+
+	// Websocket subprotocol for varint length framing
+	subprotocol = "ehhworld.v2"
+
+	// Time allowed for logging in, or for the server to welcome a bot
+	connectTimeout = 10 * time.Second
+
+	// Time allowed to write a message to the server
+	writeWait = 10 * time.Second
+
+	// Buffer of messages waiting to be written, such as snapshot acknowledgements
+	outboundBuffer = 64
+)
+
+// Errors
+var (
+	// errFrameTruncated is returned when a framed message from the server is cut short
+	errFrameTruncated = errors.New("framed message truncated")
+)
+
+// target is the server bots connect to
+type target struct {
+	apiURL       string
+	websocketURL string
+	origin       string
+	client       *http.Client
+}
+
+// newTarget describes a server by host and port, and the origin bots claim to be from
+func newTarget(server string, secure bool, origin string) *target {
+	httpScheme, websocketScheme := "http", "ws"
+	if secure {
+		httpScheme, websocketScheme = "https", "wss"
+	}
+
+	return &target{
+		apiURL:       httpScheme + "://" + server + "/api",
+		websocketURL: websocketScheme + "://" + server + "/ws",
+		origin:       origin,
+		client:       &http.Client{Timeout: connectTimeout},
+	}
+}
+
+// post sends credentials to an API endpoint, decoding the JSON reply into reply when it is not nil
+func (t *target) post(endpoint, name, password string, reply interface{}) (int, error) {
+	body, err := json.Marshal(map[string]string{"name": name, "password": password})
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := t.client.Post(t.apiURL+endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if reply != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(reply); err != nil {
+			return resp.StatusCode, err
+		}
+	}
+
+	return resp.StatusCode, nil
+}
+
+// bot is one headless client, connected over a websocket
+type bot struct {
+	name     string
+	password string
+	behavior Behavior
+	target   *target
+	stats    *stats
+	random   *rand.Rand
+
+	conn     *websocket.Conn
+	outbound chan *protobuf.Message
+
+	// Send times of inputs the server has not acknowledged yet, by sequence
+	mutex   sync.Mutex
+	pending map[uint32]time.Time
+}
+
+// newBot creates a bot that logs in as name, registering the account if needed
+func newBot(name, password string, behavior Behavior, target *target, stats *stats, seed int64) *bot {
+	return &bot{
+		name:     name,
+		password: password,
+		behavior: behavior,
+		target:   target,
+		stats:    stats,
+		random:   rand.New(rand.NewSource(seed)),
+		outbound: make(chan *protobuf.Message, outboundBuffer),
+		pending:  make(map[uint32]time.Time),
+	}
+}
+
+// run connects the bot and plays until stop is closed or the connection is lost
+func (b *bot) run(stop <-chan struct{}) {
+	token, err := b.login()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"bot":   b.name,
+			"error": err,
+		}).Warn("Bot failed to log in")
+
+		b.stats.connectFailed()
+		return
+	}
+
+	// Logging in is dominated by password hashing, so only the connection itself is timed
+	start := time.Now()
+	if err := b.connect(token); err != nil {
+		log.WithFields(log.Fields{
+			"bot":   b.name,
+			"error": err,
+		}).Warn("Bot failed to connect")
+
+		b.stats.connectFailed()
+		return
+	}
+	b.stats.connect(time.Now().Sub(start))
+
+	log.WithFields(log.Fields{
+		"bot":      b.name,
+		"behavior": b.behavior.Name(),
+	}).Debug("Bot connected")
+
+	lost := make(chan error, 1)
+	go func() {
+		lost <- b.read()
+	}()
+
+	var inputs <-chan time.Time
+	if period := b.behavior.Period(); period > 0 {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		inputs = ticker.C
+	}
+
+	var sequence uint32
+	for {
+		select {
+		case <-stop:
+			b.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeWait))
+			b.conn.Close()
+			b.stats.disconnect(false)
+			return
+		case err := <-lost:
+			log.WithFields(log.Fields{
+				"bot":   b.name,
+				"error": err,
+			}).Warn("Bot disconnected")
+
+			b.conn.Close()
+			b.stats.disconnect(true)
+			return
+		case msg := <-b.outbound:
+			// Write errors surface as the connection being lost
+			b.write(msg)
+		case <-inputs:
+			sequence++
+			msg := b.behavior.Next(b.random, sequence)
+			if msg == nil {
+				continue
+			}
+
+			b.mutex.Lock()
+			b.pending[sequence] = time.Now()
+			b.mutex.Unlock()
+			b.write(msg)
+		}
+	}
+}
+
+// login registers the bot's account if it is missing, then logs in, returning the session token
+func (b *bot) login() (string, error) {
+	status, err := b.target.post("/register", b.name, b.password, nil)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusCreated && status != http.StatusConflict {
+		return "", fmt.Errorf("registering got status %d", status)
+	}
+
+	login := struct {
+		Token string `json:"token"`
+	}{}
+	status, err = b.target.post("/login", b.name, b.password, &login)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("logging in got status %d", status)
+	}
+
+	return login.Token, nil
+}
+
+// connect opens a websocket with a session token, and says hello, waiting to be welcomed
+func (b *bot) connect(token string) error {
+	header := http.Header{}
+	header.Set("Origin", b.target.origin)
+	dialer := &websocket.Dialer{Subprotocols: []string{subprotocol}, HandshakeTimeout: connectTimeout}
+	conn, _, err := dialer.Dial(b.target.websocketURL+"?token="+url.QueryEscape(token), header)
+	if err != nil {
+		return err
+	}
+	b.conn = conn
+
+	if err := b.write(&protobuf.Message{Payload: &protobuf.Message_Hello{Hello: &protobuf.Hello{ProtocolVersion: protocolVersion}}}); err != nil {
+		conn.Close()
+		return err
+	}
+
+	// Wait for the welcome, handling anything sent before it as usual
+	conn.SetReadDeadline(time.Now().Add(connectTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	for {
+		messages, err := b.readMessages()
+		if err != nil {
+			conn.Close()
+			return err
+		}
+
+		welcomed := false
+		for _, msg := range messages {
+			if reject := msg.GetReject(); reject != nil {
+				conn.Close()
+				return fmt.Errorf("rejected: %v", reject.Reason)
+			}
+			if msg.GetWelcome() != nil {
+				welcomed = true
+			}
+			b.handle(msg)
+		}
+		if welcomed {
+			return nil
+		}
+	}
+}
+
+// read handles messages from the server until the connection fails
+func (b *bot) read() error {
+	for {
+		messages, err := b.readMessages()
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range messages {
+			b.handle(msg)
+		}
+	}
+}
+
+// readMessages reads one websocket message, and decodes the messages packed into it
+func (b *bot) readMessages() ([]*protobuf.Message, error) {
+	_, data, err := b.conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*protobuf.Message, 0, 1)
+	size := len(data)
+	for len(data) > 0 {
+		length, n := binary.Uvarint(data)
+		if n <= 0 || length > uint64(len(data)-n) {
+			return nil, errFrameTruncated
+		}
+
+		msg := &protobuf.Message{}
+		if err := proto.Unmarshal(data[n:n+int(length)], msg); err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+		data = data[n+int(length):]
+	}
+
+	b.stats.messagesReceived(len(messages), size)
+	return messages, nil
+}
+
+// handle acknowledges snapshots and the inputs they acknowledge, and counts errors
+func (b *bot) handle(msg *protobuf.Message) {
+	switch payload := msg.Payload.(type) {
+	case *protobuf.Message_Snapshot:
+		b.acknowledged(payload.Snapshot.AckSequence)
+
+		ack := &protobuf.Message{Payload: &protobuf.Message_SnapshotAck{SnapshotAck: &protobuf.SnapshotAck{Tick: payload.Snapshot.Tick}}}
+		select {
+		case b.outbound <- ack:
+		default:
+			// Falling behind; the next snapshot will be acknowledged instead
+		}
+	case *protobuf.Message_Error:
+		log.WithFields(log.Fields{
+			"bot":    b.name,
+			"code":   payload.Error.Code,
+			"reason": payload.Error.Reason,
+		}).Debug("Bot received error")
+
+		b.stats.errorReceived()
+	}
+}
+
+// acknowledged records the round trip time of every input up to sequence, which the server has now processed
+func (b *bot) acknowledged(sequence uint32) {
+	now := time.Now()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for pending, sent := range b.pending {
+		if pending <= sequence {
+			b.stats.roundTrip(now.Sub(sent))
+			delete(b.pending, pending)
+		}
+	}
+}
+
+// write frames a message and sends it in its own websocket message.
+// Only one goroutine may write at a time; after connecting, that is the bot's run loop.
+func (b *bot) write(msg *protobuf.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(header, uint64(len(data)))
+
+	b.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	if err := b.conn.WriteMessage(websocket.BinaryMessage, append(header[:n], data...)); err != nil {
+		return err
+	}
+
+	b.stats.messageSent()
+	return nil
+}
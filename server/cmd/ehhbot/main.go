@@ -0,0 +1,145 @@
+package main
+
+// ehhbot load tests a server with headless bots speaking the real protocol over websockets.
+// Each bot registers and logs in over the HTTP API, connects, then follows a scripted behavior,
+// while connect latency, input round trip time, disconnects and throughput are reported.
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/namsral/flag"
+
+	colorable "github.com/mattn/go-colorable"
+	log "github.com/sirupsen/logrus"
+)
+
+var server string
+var secure bool
+var origin string
+var bots int
+var duration time.Duration
+var ramp time.Duration
+var behaviors string
+var inputRate float64
+var prefix string
+var password string
+var reportInterval time.Duration
+var level string
+var seed int64
+
+func init() {
+	// Define input parameters
+	flag.StringVar(&server, "server", "localhost:8081", "The host and port of the server to test.")
+	flag.BoolVar(&secure, "secure", false, "Whether to connect with https and wss rather than http and ws.")
+	flag.StringVar(&origin, "origin", "http://localhost:8080", "The web page origin bots claim to connect from, which the server must allow.")
+	flag.IntVar(&bots, "bots", 10, "Number of bots to connect.")
+	flag.DurationVar(&duration, "duration", time.Minute, "How long to run the test for, once the first bot starts. (e.g. '30s', '5m')")
+	flag.DurationVar(&ramp, "ramp", 10*time.Millisecond, "Delay between starting bots, to ramp up load rather than connecting all at once.")
+	flag.StringVar(&behaviors, "behaviors", "walk", "Comma separated behaviors handed out to bots in turn. 'walk' wanders randomly, 'attack' spams attacks, 'idle' only acknowledges snapshots.")
+	flag.Float64Var(&inputRate, "inputRate", 5, "Inputs per second sent by walking and attacking bots.")
+	flag.StringVar(&prefix, "prefix", "bot", "Prefix of bot account names, which are numbered after it. (e.g. 'bot_12')")
+	flag.StringVar(&password, "password", "ehhbot-password", "Password of bot accounts. Accounts are registered when missing.")
+	flag.DurationVar(&reportInterval, "reportInterval", 5*time.Second, "How often to report stats while running.")
+	flag.StringVar(&level, "level", "info", "the log level to output during execution. (e.g. 'panic', 'fatal', 'error', 'warn', 'info', or 'debug'")
+	flag.Int64Var(&seed, "seed", time.Now().UTC().UnixNano(), "Seed for bot behaviors, defaults to random seed.")
+}
+
+func main() {
+	flag.Parse()
+	rand.Seed(seed)
+
+	// Configure logging
+	log.SetFormatter(&log.TextFormatter{ForceColors: true})
+	log.SetOutput(colorable.NewColorableStdout())
+	logLevel, err := log.ParseLevel(level)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.SetLevel(logLevel)
+
+	names := splitList(behaviors)
+	for _, name := range names {
+		if _, err := newBehavior(name, inputRate); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if len(names) == 0 {
+		log.Fatal("At least one behavior is needed")
+	}
+
+	target := newTarget(server, secure, origin)
+	stats := newStats()
+	stop := make(chan struct{})
+
+	// Stop early on interrupt
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt)
+	go func() {
+		select {
+		case <-signalChan:
+			log.Info("Interrupted, stopping bots")
+		case <-time.After(duration):
+		}
+		close(stop)
+	}()
+
+	log.WithFields(log.Fields{
+		"server":    server,
+		"bots":      bots,
+		"duration":  duration,
+		"behaviors": names,
+	}).Info("Starting bots")
+
+	// Start bots, ramping up
+	var wg sync.WaitGroup
+	go func() {
+		ticker := time.NewTicker(reportInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				stats.report(false)
+			}
+		}
+	}()
+
+	for i := 0; i < bots; i++ {
+		behavior, _ := newBehavior(names[i%len(names)], inputRate)
+		bot := newBot(fmt.Sprintf("%s_%d", prefix, i), password, behavior, target, stats, rand.Int63())
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bot.run(stop)
+		}()
+
+		select {
+		case <-stop:
+			i = bots
+		case <-time.After(ramp):
+		}
+	}
+
+	wg.Wait()
+	stats.report(true)
+}
+
+// splitList splits a comma separated list, dropping empty values
+func splitList(list string) []string {
+	values := make([]string, 0)
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
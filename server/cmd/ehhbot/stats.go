@@ -0,0 +1,143 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// stats gathers measurements from all bots
+type stats struct {
+	mutex sync.Mutex
+	start time.Time
+
+	// Bots connected now, connection attempts that failed, and connections lost before the test ended
+	connected   int
+	failed      int
+	disconnects int
+
+	// Messages and bytes sent and received, and protocol errors received
+	sent          int
+	received      int
+	bytesReceived int
+	errors        int
+
+	// How long it took bots to log in and be welcomed, and for inputs to be acknowledged in a snapshot
+	connectLatencies []time.Duration
+	roundTrips       []time.Duration
+}
+
+// newStats creates empty stats, starting the clock for throughput
+func newStats() *stats {
+	return &stats{start: time.Now()}
+}
+
+func (s *stats) connect(latency time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.connected++
+	s.connectLatencies = append(s.connectLatencies, latency)
+}
+
+func (s *stats) connectFailed() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.failed++
+}
+
+// disconnect records a bot leaving, and whether the server dropped it before the test ended
+func (s *stats) disconnect(unexpected bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.connected--
+	if unexpected {
+		s.disconnects++
+	}
+}
+
+func (s *stats) messageSent() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sent++
+}
+
+func (s *stats) messagesReceived(count, bytes int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.received += count
+	s.bytesReceived += bytes
+}
+
+func (s *stats) errorReceived() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.errors++
+}
+
+func (s *stats) roundTrip(rtt time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.roundTrips = append(s.roundTrips, rtt)
+}
+
+// report logs the stats so far. The final report also logs latency percentiles over the whole test.
+func (s *stats) report(final bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	elapsed := time.Now().Sub(s.start)
+	fields := log.Fields{
+		"elapsed":        elapsed.Round(time.Second),
+		"connected":      s.connected,
+		"failed":         s.failed,
+		"disconnects":    s.disconnects,
+		"errors":         s.errors,
+		"sent/s":         int(float64(s.sent) / elapsed.Seconds()),
+		"received/s":     int(float64(s.received) / elapsed.Seconds()),
+		"received KB/s":  int(float64(s.bytesReceived) / 1024 / elapsed.Seconds()),
+		"connect p50":    percentile(s.connectLatencies, 0.5),
+		"connect p99":    percentile(s.connectLatencies, 0.99),
+		"round trip p50": percentile(s.roundTrips, 0.5),
+		"round trip p99": percentile(s.roundTrips, 0.99),
+	}
+
+	if !final {
+		log.WithFields(fields).Info("Load test running")
+		return
+	}
+
+	fields["connect p95"] = percentile(s.connectLatencies, 0.95)
+	fields["connect max"] = percentile(s.connectLatencies, 1)
+	fields["round trip p95"] = percentile(s.roundTrips, 0.95)
+	fields["round trip max"] = percentile(s.roundTrips, 1)
+	fields["round trips"] = len(s.roundTrips)
+	log.WithFields(fields).Info("Load test finished")
+}
+
+// percentile returns the duration that a fraction p of samples are no longer than, or zero without samples.
+// Sorts the samples in place.
+func percentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	index := int(p*float64(len(samples))+0.5) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(samples) {
+		index = len(samples) - 1
+	}
+
+	return samples[index]
+}
@@ -53,6 +53,11 @@ func NewCollision() *Collision {
 	}
 }
 
+// Size returns the number of objects in the collision tree
+func (c *Collision) Size() int {
+	return c.rtree.Size()
+}
+
 // AddObject adds an object, that implements the Trackable interface, to the collision system.
 // If the object has moved, it MUST be updated in the collision system.
 func (c *Collision) AddObject(object Trackable) {
@@ -111,11 +116,7 @@ func (c *Collision) GetWithinRect(point *utility.PositionHighResolution, size *u
 func (c *Collision) objectTypeFlagsBitSetFilterGenerator(flags *object.TypeFlagsBitSet) rtreego.Filter {
 	return func(results []rtreego.Spatial, object rtreego.Spatial) (refuse, abort bool) {
 		refObject := object.(*objectWrapper).ref.(Trackable)
-		// Return valid if flags match
-		if refObject.GetObjectFlags().Flags.Isset(flags.Flags) {
-			return true, false
-		}
-
-		return false, false
+		// Refuse unless flags match
+		return !refObject.GetObjectFlags().Flags.Isset(flags.Flags), false
 	}
 }
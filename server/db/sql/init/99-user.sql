@@ -8,4 +8,6 @@ CREATE USER ehhworld_db_docker_user WITH PASSWORD 'ehhworlddb';
 -- Grant user control of database, tables, indexes
 GRANT ALL PRIVILEGES ON DATABASE ehhworld TO ehhworld_db_docker_user;
 GRANT ALL PRIVILEGES ON ALL TABLES IN SCHEMA public TO ehhworld_db_docker_user;
-GRANT ALL PRIVILEGES ON ALL SEQUENCES IN SCHEMA public TO ehhworld_db_docker_user;
\ No newline at end of file
+GRANT ALL PRIVILEGES ON ALL SEQUENCES IN SCHEMA public TO ehhworld_db_docker_user;
+-- Grant user control of the schema, so the server can run migrations
+GRANT ALL ON SCHEMA public TO ehhworld_db_docker_user;
@@ -0,0 +1,40 @@
+package game
+
+import (
+	"sync"
+)
+
+// Command is a change to the game state.
+// Commands may be queued from any goroutine, and are run in order by the game loop at the start of an update.
+type Command func(*Game)
+
+// commandQueue is a thread-safe first-in first-out queue of commands
+type commandQueue struct {
+	mutex    sync.Mutex
+	commands []Command
+}
+
+// newCommandQueue creates an empty command queue
+func newCommandQueue() *commandQueue {
+	return &commandQueue{
+		commands: make([]Command, 0),
+	}
+}
+
+// push adds a command to the back of the queue
+func (q *commandQueue) push(command Command) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.commands = append(q.commands, command)
+}
+
+// drain empties the queue, returning all queued commands in the order they were pushed
+func (q *commandQueue) drain() []Command {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	commands := q.commands
+	q.commands = make([]Command, 0, len(commands))
+	return commands
+}
@@ -4,6 +4,7 @@ package game
 
 import (
 	"math"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -24,20 +25,38 @@ type IGameObject interface {
 	Render(int64, *Game)
 }
 
+// IViewer interface defines what a struct must implement to be sent snapshots of the game state
+type IViewer interface {
+	// View emits a snapshot of the game state, as of the provided tick, to the viewer
+	View(uint32, *Game)
+}
+
 // Game is a struct the represents a game
 type Game struct {
 	// Game loop
 	running      bool
 	frame        uint16
+	ticks        uint32 // Counts every tick without wrapping like frame does, to tell snapshots apart
 	startTime    time.Time
 	previousTick time.Time
 	accumulator  time.Duration
 	ticker       *time.Ticker
+	tickRate     int
+	quit         chan struct{}
+	stopped      chan struct{}
+
+	// Snapshots
+	sendRate            int
+	snapshotPeriod      time.Duration
+	snapshotAccumulator time.Duration
 
 	// Data managers
-	gamemap   *gamemap.GameMap
-	collision *collision.Collision
-	objects   map[IGameObject]IGameObject
+	gamemap      *gamemap.GameMap
+	collision    *collision.Collision
+	objects      map[IGameObject]IGameObject
+	viewers      map[IViewer]IViewer
+	commands     *commandQueue
+	lastEntityID uint32
 	// playerBlocks map[*gamemap.Block]*player.Player
 }
 
@@ -49,22 +68,35 @@ func NewGame(gamemap *gamemap.GameMap) *Game {
 		gamemap:   gamemap,
 		collision: collision.NewCollision(),
 		objects:   make(map[IGameObject]IGameObject),
+		viewers:   make(map[IViewer]IViewer),
+		commands:  newCommandQueue(),
 		// network: network,
 	}
 }
 
-// Start begins the game loop
-func (g *Game) Start(tickRate int) {
+// Start begins the game loop.
+// tickRate is the times per second the game ticks. sendRate is the times per second viewers are sent snapshots.
+func (g *Game) Start(tickRate, sendRate int) {
 	log.Info("Starting game.")
 	g.running = true
 	g.startTime = time.Now()
 	g.accumulator = time.Duration(0)
 	g.previousTick = g.startTime
 	g.ticker = time.NewTicker(time.Second / time.Duration(tickRate))
+	g.tickRate = tickRate
+	g.sendRate = sendRate
+	g.snapshotPeriod = time.Second / time.Duration(sendRate)
+	g.snapshotAccumulator = time.Duration(0)
+	g.quit = make(chan struct{})
+	g.stopped = make(chan struct{})
 
 	go func() {
-		for g.running {
+		defer close(g.stopped)
+
+		for {
 			select {
+			case <-g.quit:
+				return
 			case t := <-g.ticker.C:
 				// Increment frame number
 				if g.frame == math.MaxUint16 {
@@ -74,13 +106,15 @@ func (g *Game) Start(tickRate int) {
 				}
 
 				// Tick game
+				g.ticks++
 				g.tick(t)
 			}
 		}
 	}()
 }
 
-// Stop ends the game loop
+// Stop ends the game loop, waiting for the current tick to finish.
+// Commands still queued are then run, so that nothing queued before stopping is lost.
 func (g *Game) Stop() {
 	log.WithFields(log.Fields{
 		"uptime":     time.Now().Sub(g.startTime),
@@ -88,7 +122,16 @@ func (g *Game) Stop() {
 	}).Info("Stopping game.")
 
 	g.running = false
+	close(g.quit)
+	<-g.stopped
 	g.ticker.Stop()
+	g.runCommands()
+}
+
+// Flush runs commands queued since the game stopped, such as saves of players removed while shutting down.
+// Must only be called after Stop.
+func (g *Game) Flush() {
+	g.runCommands()
 }
 
 // GetGameMap returns the game map
@@ -101,12 +144,44 @@ func (g *Game) GetCollision() *collision.Collision {
 	return g.collision
 }
 
+// GetFrame returns the current frame number of the game loop
+func (g *Game) GetFrame() uint16 {
+	return g.frame
+}
+
+// GetStartTime returns when the game loop was started
+func (g *Game) GetStartTime() time.Time {
+	return g.startTime
+}
+
+// GetTickRate returns the times per second the game ticks
+func (g *Game) GetTickRate() int {
+	return g.tickRate
+}
+
+// GetSendRate returns the times per second viewers are sent snapshots
+func (g *Game) GetSendRate() int {
+	return g.sendRate
+}
+
+// NewEntityID returns a new ID, unique within the game, to identify an entity by
+func (g *Game) NewEntityID() uint32 {
+	return atomic.AddUint32(&g.lastEntityID, 1)
+}
+
 // // GetMapBlocksSize returns the size of the game map in blocks
 // func (g *Game) GetMapBlocksSize() *utility.Size {
 // 	return g.gamemap.GetBlocksSize()
 // }
 
+// Enqueue queues a command to be run by the game loop at the start of the next update.
+// This is the only safe way to change the game state from outside of the game loop.
+func (g *Game) Enqueue(command Command) {
+	g.commands.push(command)
+}
+
 // AddObject adds an object to the game to be tracked / simulated.
+// Must only be called from the game loop, such as from a queued Command.
 func (g *Game) AddObject(object IGameObject) {
 	g.objects[object] = object
 
@@ -117,6 +192,7 @@ func (g *Game) AddObject(object IGameObject) {
 }
 
 // RemoveObject removes an object from the game.
+// Must only be called from the game loop, such as from a queued Command.
 func (g *Game) RemoveObject(object IGameObject) {
 	delete(g.objects, object)
 
@@ -136,7 +212,37 @@ func (g *Game) RemoveObject(object IGameObject) {
 	// }
 }
 
+// HasObject returns true if an object is in the game.
+// Must only be called from the game loop, such as from a queued Command.
+func (g *Game) HasObject(object IGameObject) bool {
+	_, exists := g.objects[object]
+	return exists
+}
+
+// AddViewer adds a viewer to the game, to be sent snapshots of the game state.
+// Must only be called from the game loop, such as from a queued Command.
+func (g *Game) AddViewer(viewer IViewer) {
+	g.viewers[viewer] = viewer
+}
+
+// RemoveViewer removes a viewer from the game.
+// Must only be called from the game loop, such as from a queued Command.
+func (g *Game) RemoveViewer(viewer IViewer) {
+	delete(g.viewers, viewer)
+}
+
 func (g *Game) tick(currentTime time.Time) {
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start)
+		tickDuration.Observe(duration.Seconds())
+		if duration > time.Second/time.Duration(g.tickRate) {
+			tickOverruns.Inc()
+		}
+		objectCount.Set(float64(len(g.objects)))
+		collisionSize.Set(float64(g.collision.Size()))
+	}()
+
 	elapsed := currentTime.Sub(g.previousTick)
 	g.previousTick = currentTime
 	g.accumulator += elapsed
@@ -146,10 +252,13 @@ func (g *Game) tick(currentTime time.Time) {
 		"accumulated dt":    g.accumulator,
 	}).Debug("Game tick call.")
 
+	updates := 0
 	for g.accumulator >= millisecondPerUpdate {
 		g.update()
 		g.accumulator -= millisecondPerUpdate
+		updates++
 	}
+	updatesPerTick.Observe(float64(updates))
 
 	frac := float64(g.accumulator) / float64(millisecondPerUpdate)
 
@@ -159,6 +268,13 @@ func (g *Game) tick(currentTime time.Time) {
 	}).Debug("Game about to render.")
 
 	g.render(time.Duration(float64(millisecondPerUpdate) * frac))
+
+	// Send snapshots at their own rate, independent of the tick rate
+	g.snapshotAccumulator += elapsed
+	if g.snapshotAccumulator >= g.snapshotPeriod {
+		g.snapshotAccumulator %= g.snapshotPeriod
+		g.snapshot()
+	}
 }
 
 // update simulates the game using a consistent time step
@@ -168,11 +284,21 @@ func (g *Game) update() {
 		"accumulated dt": g.accumulator,
 	}).Debug("Game update call.")
 
+	// Apply queued changes first, in the order they arrived
+	g.runCommands()
+
 	for _, o := range g.objects {
 		o.Update(int64(millisecondPerUpdate/time.Millisecond), g)
 	}
 }
 
+// runCommands runs all queued commands, in the order they were queued
+func (g *Game) runCommands() {
+	for _, command := range g.commands.drain() {
+		command(g)
+	}
+}
+
 // render updates clients of current game state, interpolated dt into the future
 func (g *Game) render(dt time.Duration) {
 	log.WithFields(log.Fields{
@@ -183,3 +309,15 @@ func (g *Game) render(dt time.Duration) {
 		o.Render(int64(dt/time.Millisecond), g)
 	}
 }
+
+// snapshot sends the current game state to all viewers
+func (g *Game) snapshot() {
+	log.WithFields(log.Fields{
+		"frame":   g.frame,
+		"viewers": len(g.viewers),
+	}).Debug("Game snapshot call.")
+
+	for _, v := range g.viewers {
+		v.View(g.ticks, g)
+	}
+}
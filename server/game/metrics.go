@@ -0,0 +1,19 @@
+package game
+
+import "bitbucket.org/ehhio/ehhworldserver/server/metrics"
+
+// Metrics about the game loop
+var (
+	tickDuration = metrics.NewHistogram("ehhworld_game_tick_duration_seconds",
+		"Time taken by each game tick, including updates, rendering and snapshots.",
+		[]float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.0167, 0.025, 0.05, 0.1, 0.25})
+	tickOverruns = metrics.NewCounter("ehhworld_game_tick_overruns_total",
+		"Game ticks that took longer than the tick period, delaying the next tick.")
+	updatesPerTick = metrics.NewHistogram("ehhworld_game_updates_per_tick",
+		"Fixed time step updates run by each game tick, to catch up with the time passed.",
+		[]float64{0, 1, 2, 3, 4, 6, 8, 16})
+	objectCount = metrics.NewGauge("ehhworld_game_objects",
+		"Objects simulated by the game.")
+	collisionSize = metrics.NewGauge("ehhworld_game_collision_objects",
+		"Objects tracked in the collision tree.")
+)
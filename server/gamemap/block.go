@@ -85,3 +85,14 @@ func (b *Block) GetCellAt(x, y int) *Cell {
 func (b *Block) SetPosition(x, y int) {
 	b.position = &utility.Position{X: x, Y: y}
 }
+
+// GetPosition Get the position of a world Block
+// Returned position is in World space coordinates.
+func (b *Block) GetPosition() *utility.Position {
+	return b.position
+}
+
+// GetSize Get the dimensions of a world Block
+func (b *Block) GetSize() *utility.Size {
+	return b.size
+}
@@ -55,3 +55,13 @@ func NewCell(position utility.Position, biomeDef *BiomeDefinition, parent *Block
 func (c *Cell) String() string {
 	return fmt.Sprintf("<Cell>[%v, %v, %v]", c.position, c.biome, c.shape)
 }
+
+// GetBiome Get the biome of the Cell
+func (c *Cell) GetBiome() Biome {
+	return c.biome.biome
+}
+
+// GetShape Get the shape of the Cell
+func (c *Cell) GetShape() CellShape {
+	return c.shape
+}
@@ -19,10 +19,11 @@ import (
 
 // GameMap A map made up of blocks containing cells of biome data.
 type GameMap struct {
-	size      *utility.Size // Size of the game map in cells
-	seed      int64         // pRNG seed used to generate the game map
-	blocks    *BlockMatrix  // 2D matrix containing Blocks
-	blockSize *utility.Size // Size of Blocks in the map
+	size      *utility.Size  // Size of the game map in cells
+	seed      int64          // pRNG seed used to generate the game map
+	mode      GenerationMode // Generation mode used to generate the game map
+	blocks    *BlockMatrix   // 2D matrix containing Blocks
+	blockSize *utility.Size  // Size of Blocks in the map
 }
 
 // NewGameMap creates a new empty game map.
@@ -69,6 +70,11 @@ func (m *GameMap) GetSeed() int64 {
 	return m.seed
 }
 
+// GetGenerationMode returns the generation mode used to generate the game map
+func (m *GameMap) GetGenerationMode() GenerationMode {
+	return m.mode
+}
+
 // GetBlockSize returns the size of a single block in the game map
 func (m *GameMap) GetBlockSize() *utility.Size {
 	return m.blockSize
@@ -114,6 +120,13 @@ func (m *GameMap) GetBlockAt(x, y int) *Block {
 	return m.blocks.matrix[targetBlockPosition.X][targetBlockPosition.Y]
 }
 
+// GetBlockPositionAt Get the position of the Block in the Block matrix that contains a position
+// x and y are Map space coordinates
+func (m *GameMap) GetBlockPositionAt(x, y int) *utility.Position {
+	blockPosition := m.mapToBlockCoordinates(utility.Position{X: x, Y: y})
+	return &blockPosition
+}
+
 // GetCellAt Get the Cell from the Block in the game Map at a position
 // x and y are Map space coordinates
 func (m *GameMap) GetCellAt(x, y int) *Cell {
@@ -139,8 +152,9 @@ func (m *GameMap) Generate(mode GenerationMode, seed int64) {
 		"size": m.size,
 	}).Info("Generating world.")
 
-	// Record seed
+	// Record seed and mode
 	m.seed = seed
+	m.mode = mode
 
 	// Init biome data
 	biomeImg := prepareBiomeData(*m.size, "../assets/image/biomes.v5.png")
@@ -5,6 +5,7 @@ import (
 	"math/rand"
 	"os"
 	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/namsral/flag"
@@ -12,9 +13,13 @@ import (
 	colorable "github.com/mattn/go-colorable"
 	log "github.com/sirupsen/logrus"
 
+	"bitbucket.org/ehhio/ehhworldserver/server/account"
 	"bitbucket.org/ehhio/ehhworldserver/server/game"
 	"bitbucket.org/ehhio/ehhworldserver/server/gamemap"
 	"bitbucket.org/ehhio/ehhworldserver/server/network"
+	"bitbucket.org/ehhio/ehhworldserver/server/store"
+	"bitbucket.org/ehhio/ehhworldserver/server/store/memory"
+	"bitbucket.org/ehhio/ehhworldserver/server/store/postgres"
 )
 
 var quiet bool
@@ -25,8 +30,28 @@ var mode int
 var seed int64
 var mapBlockSize int
 var address string
+var tcpAddress string
 var serveGame bool
 var tick int
+var sendRate int
+var viewWidth float64
+var viewHeight float64
+var database string
+var migrate string
+var saveInterval time.Duration
+var reconnectGrace time.Duration
+var rateLimit string
+var origins string
+var tlsCert string
+var tlsKey string
+var autocertHosts string
+var autocertCache string
+var autocertEmail string
+var rateLimits string
+var loginRateLimit string
+var chatWords string
+var admins string
+var console bool
 
 func init() {
 	// Define input parameters
@@ -38,8 +63,28 @@ func init() {
 	flag.Int64Var(&seed, "seed", time.Now().UTC().UnixNano(), "World generation seed, defaults to random seed.")
 	flag.IntVar(&mapBlockSize, "mapBlockSize", 4, "The size of blocks to break the game map into for transport.")
 	flag.StringVar(&address, "address", ":8081", "The webserver address to listen on.")
+	flag.StringVar(&tcpAddress, "tcpAddress", "", "The address to accept plain TCP connections from native clients and bots on, such as ':8082'. Disabled when empty.")
 	flag.BoolVar(&serveGame, "serve", false, "Start a game loop and run a webserver to serve the game world.")
 	flag.IntVar(&tick, "tickrate", 60, "Times per second the game ticks and then updates players.")
+	flag.IntVar(&sendRate, "sendrate", 20, "Times per second clients are sent snapshots of the game state.")
+	flag.Float64Var(&viewWidth, "viewWidth", 32, "Width of the area around a player that is sent to their client, in cells.")
+	flag.Float64Var(&viewHeight, "viewHeight", 32, "Height of the area around a player that is sent to their client, in cells.")
+	flag.StringVar(&database, "database", "", "Postgres connection string to persist game data to. Data is kept in memory when empty.")
+	flag.DurationVar(&saveInterval, "saveInterval", time.Minute, "How often connected players are saved, besides when they disconnect. (e.g. '30s', '5m')")
+	flag.DurationVar(&reconnectGrace, "reconnectGrace", 30*time.Second, "How long players linger in the game after losing their connection, waiting for it to reconnect. (e.g. '30s', '0s' to remove them right away)")
+	flag.StringVar(&rateLimit, "rateLimit", "30/60", "Messages per second each client may send of each type, and the burst allowed above that, as 'rate/burst'. A rate of 0 is unlimited.")
+	flag.StringVar(&loginRateLimit, "loginRateLimit", "0.2/5", "Login attempts per second allowed from each address, and the burst allowed above that, as 'rate/burst'. A rate of 0 is unlimited.")
+	flag.StringVar(&rateLimits, "rateLimits", "Hello=1/3,ChatSend.Global=0.2/3,ChatSend.Local=1/5,ChatSend.Whisper=1/5", "Budgets for specific message types or chat channels that override rateLimit, as comma separated 'type=rate/burst'. (e.g. 'Move=30/60,Attack=5/10,ChatSend.Global=0.2/3')")
+	flag.StringVar(&chatWords, "chatWords", "", "File of words to mask in chat, one per line.")
+	flag.StringVar(&admins, "admins", "", "Comma separated names of the accounts that may connect with mode=admin and run admin commands.")
+	flag.BoolVar(&console, "console", true, "Read admin commands from stdin while serving.")
+	flag.StringVar(&origins, "origins", "http://localhost:8080", "Comma separated web page origins allowed to connect, such as 'https://ehh.io,https://*.ehh.io'. Origins without a scheme allow http and https.")
+	flag.StringVar(&tlsCert, "tlsCert", "", "Certificate file to serve TLS with, along with tlsKey.")
+	flag.StringVar(&tlsKey, "tlsKey", "", "Private key file to serve TLS with, along with tlsCert.")
+	flag.StringVar(&autocertHosts, "autocertHosts", "", "Comma separated host names to serve TLS for, with certificates obtained automatically from Let's Encrypt.")
+	flag.StringVar(&autocertCache, "autocertCache", "certs", "Directory to cache automatically obtained certificates in.")
+	flag.StringVar(&autocertEmail, "autocertEmail", "", "Contact email address for automatically obtained certificates.")
+	flag.StringVar(&migrate, "migrate", "", "Run database migrations and exit. 'up' applies all pending migrations, 'down' undoes the latest, 'status' lists them.")
 }
 
 func main() {
@@ -68,6 +113,12 @@ func main() {
 		log.RegisterExitHandler(handler)
 	}
 
+	// Migrate, when prompted
+	if migrate != "" {
+		runMigrations(migrate, database)
+		return
+	}
+
 	// Generate the world
 	gameMap := gamemap.NewGameMap(width, height, mapBlockSize, mapBlockSize)
 	gameMap.Generate(generationMode, seed)
@@ -82,16 +133,168 @@ func main() {
 			exitChan <- 1
 		}()
 
+		// Parse message budgets
+		defaultRateLimit, err := network.ParseRateLimit(rateLimit)
+		if err != nil {
+			log.Fatal(err)
+		}
+		messageRateLimits, err := network.ParseRateLimits(rateLimits)
+		if err != nil {
+			log.Fatal(err)
+		}
+		loginLimit, err := network.ParseRateLimit(loginRateLimit)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// Load the chat filter
+		var chatFilter network.ChatFilter
+		if chatWords != "" {
+			words, err := ioutil.ReadFile(chatWords)
+			if err != nil {
+				log.Fatal(err)
+			}
+			chatFilter = network.NewWordFilter(strings.Split(string(words), "\n"))
+		}
+
+		// Parse allowed origins
+		originPolicy, err := network.NewOriginPolicy(splitList(origins))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// Connect to persistent storage
+		dataStore, err := openStore(database)
+		if err != nil {
+			log.Fatal(err)
+		}
+
 		// Start game and serve
 		game := game.NewGame(gameMap)
-		game.Start(tick)
-		hub := network.Serve(address, game)
+		game.Start(tick, sendRate)
+		hub := network.Serve(&network.Config{
+			Address:          address,
+			TCPAddress:       tcpAddress,
+			Origins:          originPolicy,
+			TLSCertFile:      tlsCert,
+			TLSKeyFile:       tlsKey,
+			AutocertHosts:    splitList(autocertHosts),
+			AutocertCacheDir: autocertCache,
+			AutocertEmail:    autocertEmail,
+			ViewWidth:        viewWidth,
+			ViewHeight:       viewHeight,
+			Accounts:         account.NewService(dataStore),
+			Store:            dataStore,
+			SaveInterval:     saveInterval,
+			ReconnectGrace:   reconnectGrace,
+			RateLimits:       messageRateLimits,
+			DefaultRateLimit: defaultRateLimit,
+			LoginRateLimit:   loginLimit,
+			ChatFilter:       chatFilter,
+			Admins:           splitList(admins),
+		}, game)
+		if console {
+			go hub.Console(os.Stdin, os.Stdout)
+		}
 
 		// Wait for kill signal
 		<-exitChan
 
-		// Stop
-		hub.Stop()
+		// Stop the game first, so it never waits on a stopped hub, then run what the hub queued while stopping
 		game.Stop()
+		hub.Stop()
+		game.Flush()
+		hub.Flush()
+		dataStore.Close()
+	}
+}
+
+// openStore connects to a Postgres database, or creates an in-memory store when no connection string is given
+func openStore(dataSourceName string) (store.Store, error) {
+	if dataSourceName == "" {
+		log.Warn("No database given, game data will be lost when the server stops")
+		return memory.NewStore(), nil
+	}
+
+	dataStore, err := postgres.Open(dataSourceName)
+	if err != nil {
+		return nil, err
 	}
+
+	// Warn about an out of date schema, rather than changing it without being asked
+	statuses, err := dataStore.MigrationStatus()
+	if err != nil {
+		dataStore.Close()
+		return nil, err
+	}
+	for _, status := range statuses {
+		if status.Applied == nil {
+			log.WithField("version", status.Migration.Version).Warn("Database has pending migrations, run with --migrate up")
+			break
+		}
+	}
+
+	return dataStore, nil
+}
+
+// runMigrations runs a migration command against a Postgres database
+func runMigrations(command, dataSourceName string) {
+	if dataSourceName == "" {
+		log.Fatal("Migrations need a database connection string")
+	}
+
+	dataStore, err := postgres.Open(dataSourceName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer dataStore.Close()
+
+	switch command {
+	case "up":
+		applied, err := dataStore.MigrateUp()
+		for _, migration := range applied {
+			log.WithFields(log.Fields{"version": migration.Version, "name": migration.Name}).Info("Applied migration")
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.WithField("count", len(applied)).Info("Database is up to date")
+	case "down":
+		migration, err := dataStore.MigrateDown()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if migration == nil {
+			log.Info("No migrations to undo")
+			return
+		}
+		log.WithFields(log.Fields{"version": migration.Version, "name": migration.Name}).Info("Undid migration")
+	case "status":
+		statuses, err := dataStore.MigrationStatus()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, status := range statuses {
+			fields := log.Fields{"version": status.Migration.Version, "name": status.Migration.Name}
+			if status.Applied != nil {
+				log.WithFields(fields).WithField("applied", *status.Applied).Info("Applied")
+			} else {
+				log.WithFields(fields).Warn("Pending")
+			}
+		}
+	default:
+		log.Fatalf("Unknown migrate command '%v', expected 'up', 'down' or 'status'", command)
+	}
+}
+
+// splitList splits a comma separated list, dropping empty entries
+func splitList(list string) []string {
+	values := make([]string, 0)
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
 }
@@ -0,0 +1,388 @@
+// Package metrics collects counters, gauges and histograms about the running server,
+// and serves them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Metric types, as named in the exposition format
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// collector is a metric that can write itself out for a scrape
+type collector interface {
+	describe() (name, help, metricType string)
+	write(w io.Writer)
+}
+
+// Registry holds the metrics served together, such as by a /metrics endpoint
+type Registry struct {
+	mutex      sync.Mutex
+	collectors []collector
+}
+
+// NewRegistry creates an empty metrics registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// DefaultRegistry is the registry metrics created by the package level constructors are registered with
+var DefaultRegistry = NewRegistry()
+
+func (r *Registry) register(c collector) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteTo writes all registered metrics in the Prometheus text exposition format, sorted by name
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mutex.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mutex.Unlock()
+
+	sort.Slice(collectors, func(i, j int) bool {
+		a, _, _ := collectors[i].describe()
+		b, _, _ := collectors[j].describe()
+		return a < b
+	})
+
+	counter := &countingWriter{writer: bufio.NewWriter(w)}
+	for _, c := range collectors {
+		name, help, metricType := c.describe()
+		fmt.Fprintf(counter, "# HELP %v %v\n", name, escapeHelp(help))
+		fmt.Fprintf(counter, "# TYPE %v %v\n", name, metricType)
+		c.write(counter)
+	}
+
+	return counter.count, counter.writer.Flush()
+}
+
+// ServeHTTP serves the registered metrics to a scraper
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		http.Error(w, "Method Not Allowed", 405)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+// Handler returns an HTTP handler serving the metrics of the default registry
+func Handler() http.Handler {
+	return DefaultRegistry
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	writer *bufio.Writer
+	count  int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.count += int64(n)
+	return n, err
+}
+
+// desc names and describes a metric, and the names of the labels telling its series apart
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+// seriesName formats the name of one series of a metric, with its label values, such as `name{label="value"}`
+func (d *desc) seriesName(suffix string, values []string, extra ...string) string {
+	pairs := make([]string, 0, len(values)+len(extra)/2)
+	for i, value := range values {
+		pairs = append(pairs, d.labels[i]+"="+quoteLabel(value))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+"="+quoteLabel(extra[i+1]))
+	}
+	if len(pairs) == 0 {
+		return d.name + suffix
+	}
+
+	return d.name + suffix + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// checkLabels panics if a series is asked for with the wrong number of label values, which is a programming error
+func (d *desc) checkLabels(values []string) {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metric %v has labels %v, but was given values %v", d.name, d.labels, values))
+	}
+}
+
+// value is a float64 that can be changed from any goroutine
+type value struct {
+	bits uint64
+}
+
+func (v *value) add(delta float64) {
+	for {
+		old := atomic.LoadUint64(&v.bits)
+		updated := math.Float64bits(math.Float64frombits(old) + delta)
+		if atomic.CompareAndSwapUint64(&v.bits, old, updated) {
+			return
+		}
+	}
+}
+
+func (v *value) set(f float64) {
+	atomic.StoreUint64(&v.bits, math.Float64bits(f))
+}
+
+func (v *value) get() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&v.bits))
+}
+
+// Counter is a count that only goes up, such as of messages sent
+type Counter struct {
+	value
+}
+
+// Inc adds one to the counter
+func (c *Counter) Inc() {
+	c.add(1)
+}
+
+// Add adds a positive amount to the counter
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		panic("counters can't go down")
+	}
+	c.add(delta)
+}
+
+// Gauge is a value that goes up and down, such as the number of connected clients
+type Gauge struct {
+	value
+}
+
+// Set sets the gauge to a value
+func (g *Gauge) Set(f float64) {
+	g.set(f)
+}
+
+// Add adds an amount to the gauge, which may be negative
+func (g *Gauge) Add(delta float64) {
+	g.add(delta)
+}
+
+// Histogram counts observations, such as durations, in buckets by their size
+type Histogram struct {
+	mutex       sync.Mutex
+	upperBounds []float64
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{upperBounds: buckets, counts: make([]uint64, len(buckets))}
+}
+
+// Observe adds an observation to the histogram
+func (h *Histogram) Observe(f float64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if i := sort.SearchFloat64s(h.upperBounds, f); i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += f
+}
+
+func (h *Histogram) write(w io.Writer, d *desc, values []string) {
+	h.mutex.Lock()
+	counts := append([]uint64(nil), h.counts...)
+	count, sum := h.count, h.sum
+	h.mutex.Unlock()
+
+	// Buckets are cumulative, each counting every observation at most its upper bound
+	var cumulative uint64
+	for i, bound := range h.upperBounds {
+		cumulative += counts[i]
+		fmt.Fprintf(w, "%v %v\n", d.seriesName("_bucket", values, "le", formatFloat(bound)), cumulative)
+	}
+	fmt.Fprintf(w, "%v %v\n", d.seriesName("_bucket", values, "le", "+Inf"), count)
+	fmt.Fprintf(w, "%v %v\n", d.seriesName("_sum", values), formatFloat(sum))
+	fmt.Fprintf(w, "%v %v\n", d.seriesName("_count", values), count)
+}
+
+// NewCounter creates a counter registered with the default registry
+func NewCounter(name, help string) *Counter {
+	v := NewCounterVec(name, help)
+	return v.With()
+}
+
+// NewGauge creates a gauge registered with the default registry
+func NewGauge(name, help string) *Gauge {
+	v := NewGaugeVec(name, help)
+	return v.With()
+}
+
+// NewHistogram creates a histogram registered with the default registry.
+// Buckets are the upper bounds of its buckets, in increasing order.
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	v := NewHistogramVec(name, help, buckets)
+	return v.With()
+}
+
+// vec holds the series of a metric, one for each combination of label values
+type vec struct {
+	desc
+	metricType string
+	create     func() interface{}
+
+	mutex  sync.Mutex
+	series map[string]interface{}
+	values map[string][]string
+}
+
+func newVec(name, help, metricType string, labels []string, create func() interface{}) *vec {
+	v := &vec{
+		desc:       desc{name: name, help: help, labels: labels},
+		metricType: metricType,
+		create:     create,
+		series:     make(map[string]interface{}),
+		values:     make(map[string][]string),
+	}
+	DefaultRegistry.register(v)
+
+	return v
+}
+
+// with returns the series for a combination of label values, creating it if needed
+func (v *vec) with(values []string) interface{} {
+	v.checkLabels(values)
+	key := strings.Join(values, "\x00")
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	s, exists := v.series[key]
+	if !exists {
+		s = v.create()
+		v.series[key] = s
+		v.values[key] = append([]string(nil), values...)
+	}
+
+	return s
+}
+
+func (v *vec) describe() (string, string, string) {
+	return v.name, v.help, v.metricType
+}
+
+func (v *vec) write(w io.Writer) {
+	v.mutex.Lock()
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	series := make([]interface{}, len(keys))
+	values := make([][]string, len(keys))
+	for i, key := range keys {
+		series[i], values[i] = v.series[key], v.values[key]
+	}
+	v.mutex.Unlock()
+
+	for i, s := range series {
+		switch s := s.(type) {
+		case *Counter:
+			fmt.Fprintf(w, "%v %v\n", v.seriesName("", values[i]), formatFloat(s.get()))
+		case *Gauge:
+			fmt.Fprintf(w, "%v %v\n", v.seriesName("", values[i]), formatFloat(s.get()))
+		case *Histogram:
+			s.write(w, &v.desc, values[i])
+		}
+	}
+}
+
+// CounterVec is a counter split into series by label values, such as by message type
+type CounterVec struct {
+	*vec
+}
+
+// NewCounterVec creates a counter split by labels, registered with the default registry
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{newVec(name, help, typeCounter, labels, func() interface{} { return &Counter{} })}
+}
+
+// With returns the counter for a combination of label values, in the order the labels were given
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.with(values).(*Counter)
+}
+
+// GaugeVec is a gauge split into series by label values
+type GaugeVec struct {
+	*vec
+}
+
+// NewGaugeVec creates a gauge split by labels, registered with the default registry
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{newVec(name, help, typeGauge, labels, func() interface{} { return &Gauge{} })}
+}
+
+// With returns the gauge for a combination of label values, in the order the labels were given
+func (v *GaugeVec) With(values ...string) *Gauge {
+	return v.with(values).(*Gauge)
+}
+
+// HistogramVec is a histogram split into series by label values
+type HistogramVec struct {
+	*vec
+}
+
+// NewHistogramVec creates a histogram split by labels, registered with the default registry
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{newVec(name, help, typeHistogram, labels, func() interface{} { return newHistogram(buckets) })}
+}
+
+// With returns the histogram for a combination of label values, in the order the labels were given
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.with(values).(*Histogram)
+}
+
+// formatFloat formats a sample value as the exposition format expects
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+
+	return fmt.Sprint(f)
+}
+
+// escapeHelp escapes backslashes and line breaks in help text
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+// quoteLabel quotes a label value, escaping backslashes, quotes and line breaks
+func quoteLabel(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
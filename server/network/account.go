@@ -0,0 +1,168 @@
+package network
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/account"
+)
+
+// Constants
+const (
+	// Number of addresses the login limiter tracks before it forgets those that are back to a full budget
+	loginLimiterPruneSize = 10000
+)
+
+// loginLimiter rate limits login attempts by remote address, to slow down password guessing.
+// Safe to use from any goroutine.
+type loginLimiter struct {
+	mutex   sync.Mutex
+	limit   RateLimit
+	buckets map[string]*tokenBucket
+}
+
+// newLoginLimiter creates a login limiter giving each address a budget. A zero rate is unlimited.
+func newLoginLimiter(limit RateLimit) *loginLimiter {
+	return &loginLimiter{limit: limit, buckets: make(map[string]*tokenBucket)}
+}
+
+// allow takes a login attempt from the budget of the address a request came from.
+// Returns false if the address has used up its budget.
+func (l *loginLimiter) allow(r *http.Request, now time.Time) bool {
+	if l.limit.Rate <= 0 {
+		return true
+	}
+
+	address, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		address = r.RemoteAddr
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if len(l.buckets) >= loginLimiterPruneSize {
+		l.prune(now)
+	}
+
+	bucket, exists := l.buckets[address]
+	if !exists {
+		bucket = newTokenBucket(l.limit, now)
+		l.buckets[address] = bucket
+	}
+
+	return bucket.take(now)
+}
+
+// prune forgets addresses whose budget has refilled, as a new full bucket would be the same
+func (l *loginLimiter) prune(now time.Time) {
+	for address, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*l.limit.Rate >= float64(l.limit.Burst) {
+			delete(l.buckets, address)
+		}
+	}
+}
+
+// credentials is the JSON body of register and login requests
+type credentials struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// readCredentials decodes the credentials of a POST request, replying with an error if it is invalid
+func readCredentials(w http.ResponseWriter, r *http.Request) (*credentials, bool) {
+	if r.Method != "POST" {
+		http.Error(w, "Method Not Allowed", 405)
+		return nil, false
+	}
+
+	creds := &credentials{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(creds); err != nil {
+		http.Error(w, "Bad Request", 400)
+		return nil, false
+	}
+
+	return creds, true
+}
+
+// writeJSON replies to a request with a JSON body
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// handleRegister creates a new account
+func handleRegister(accounts *account.Service, w http.ResponseWriter, r *http.Request) {
+	creds, ok := readCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := accounts.Register(creds.Name, creds.Password)
+	switch err {
+	case nil:
+	case account.ErrInvalidName, account.ErrInvalidPassword:
+		http.Error(w, err.Error(), 400)
+		return
+	case account.ErrNameTaken:
+		http.Error(w, err.Error(), 409)
+		return
+	default:
+		log.WithFields(log.Fields{
+			"name":  creds.Name,
+			"error": err,
+		}).Error("Account registration failed")
+
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"name": created.Name,
+	}).Info("Account registered")
+
+	writeJSON(w, 201, map[string]string{"name": created.Name})
+}
+
+// handleLogin starts a session for an account, replying with the session token.
+// Addresses trying to log in too often are refused until their budget refills.
+func handleLogin(accounts *account.Service, limiter *loginLimiter, w http.ResponseWriter, r *http.Request) {
+	creds, ok := readCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	if !limiter.allow(r, time.Now()) {
+		log.WithFields(log.Fields{
+			"address": r.RemoteAddr,
+			"name":    creds.Name,
+		}).Warn("Account login refused; Too many attempts")
+
+		http.Error(w, "Too Many Requests", 429)
+		return
+	}
+
+	token, err := accounts.Login(creds.Name, creds.Password)
+	switch err {
+	case nil:
+	case account.ErrInvalidCredentials:
+		http.Error(w, err.Error(), 401)
+		return
+	default:
+		log.WithFields(log.Fields{
+			"name":  creds.Name,
+			"error": err,
+		}).Error("Account login failed")
+
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+
+	writeJSON(w, 200, map[string]string{"name": creds.Name, "token": token})
+}
@@ -0,0 +1,321 @@
+package network
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/game"
+	"bitbucket.org/ehhio/ehhworldserver/server/npc"
+	"bitbucket.org/ehhio/ehhworldserver/server/player"
+	"bitbucket.org/ehhio/ehhworldserver/server/protobuf"
+	"bitbucket.org/ehhio/ehhworldserver/server/utility"
+)
+
+// Constants
+const (
+	// Operator name of commands run from the local console
+	consoleOperator = "console"
+)
+
+// Errors
+var (
+	// errUnknownPlayer is returned by admin commands naming a player that is not in the game
+	errUnknownPlayer = errors.New("no player by that name is in the game")
+)
+
+// adminCommand is one admin console command
+type adminCommand struct {
+	// How to call the command, for help
+	usage string
+
+	// Number of arguments the command needs at least
+	args int
+
+	// Runs the command on the hub goroutine. Errors are returned right away, otherwise the output
+	// is passed to reply once the command has run, from the game goroutine.
+	run func(h *Hub, operator string, args []string, reply func(string)) error
+}
+
+// adminCommands are the admin console commands, by name
+var adminCommands = map[string]adminCommand{
+	"list":      {usage: "list", run: adminList},
+	"kick":      {usage: "kick <player>", args: 1, run: adminKick},
+	"teleport":  {usage: "teleport <player> <x> <y>", args: 3, run: adminTeleport},
+	"broadcast": {usage: "broadcast <text>", args: 1, run: adminBroadcast},
+	"spawn":     {usage: "spawn <" + strings.Join(npc.Kinds(), "|") + "> <x> <y>", args: 3, run: adminSpawn},
+	"inspect":   {usage: "inspect <player>", args: 1, run: adminInspect},
+}
+
+// adminRequest is an admin command waiting to be run on the hub goroutine, such as from the local console
+type adminRequest struct {
+	operator string
+	line     string
+	reply    func(string)
+}
+
+// isAdmin returns true if an account may run admin commands
+func (h *Hub) isAdmin(name string) bool {
+	for _, admin := range h.config.Admins {
+		if admin == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// runAdminCommand parses and runs an admin command line on behalf of an operator, and audit logs it.
+// Must be called from the hub goroutine. Errors in the command are returned right away;
+// otherwise the output is passed to reply from the game goroutine, once the command has run.
+func (h *Hub) runAdminCommand(operator, line string, reply func(string)) error {
+	fields := strings.Fields(line)
+	err := h.dispatchAdminCommand(operator, fields, reply)
+
+	log.WithFields(log.Fields{
+		"operator": operator,
+		"command":  line,
+		"error":    err,
+	}).Warn("Admin command")
+
+	return err
+}
+
+func (h *Hub) dispatchAdminCommand(operator string, fields []string, reply func(string)) error {
+	if len(fields) == 0 {
+		return errors.New("no command given")
+	}
+
+	command, exists := adminCommands[strings.ToLower(fields[0])]
+	if !exists {
+		return fmt.Errorf("unknown command %q; commands are %v", fields[0], strings.Join(adminUsage(), ", "))
+	}
+	if len(fields)-1 < command.args {
+		return fmt.Errorf("usage: %v", command.usage)
+	}
+
+	return command.run(h, operator, fields[1:], reply)
+}
+
+// adminUsage returns how to call each admin command, sorted
+func adminUsage() []string {
+	usage := make([]string, 0, len(adminCommands))
+	for _, command := range adminCommands {
+		usage = append(usage, command.usage)
+	}
+	sort.Strings(usage)
+
+	return usage
+}
+
+// Console runs admin commands read line by line from in, such as stdin, writing their output to out.
+// Returns once in is exhausted or the hub stops.
+func (h *Hub) Console(in io.Reader, out io.Writer) {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		replies := make(chan string, 1)
+		request := &adminRequest{operator: consoleOperator, line: line, reply: func(output string) { replies <- output }}
+		select {
+		case h.adminRequests <- request:
+		case <-h.stopped:
+			return
+		}
+
+		select {
+		case output := <-replies:
+			fmt.Fprintln(out, output)
+		case <-h.stopped:
+			return
+		}
+	}
+}
+
+// handleAdminRequest runs an admin command queued from outside the hub goroutine, replying with any error
+func (h *Hub) handleAdminRequest(request *adminRequest) {
+	if err := h.runAdminCommand(request.operator, request.line, request.reply); err != nil {
+		request.reply("error: " + err.Error())
+	}
+}
+
+func handleAdminCommand(ctx *Context) error {
+	c := ctx.Client
+	if !c.admin {
+		return &HandlerError{Code: protobuf.Error_FORBIDDEN, Reason: "only admins may run commands"}
+	}
+
+	line := ctx.Message.GetAdminCommand().Command
+	err := ctx.Hub.runAdminCommand(c.name, line, func(output string) {
+		c.send(&protobuf.Message{Payload: &protobuf.Message_AdminResult{AdminResult: &protobuf.AdminResult{
+			Command: line,
+			Output:  output,
+		}}})
+	})
+	if err != nil {
+		return &HandlerError{Code: protobuf.Error_INVALID, Reason: err.Error()}
+	}
+
+	return nil
+}
+
+// parsePosition parses x and y arguments into a position inside the game map
+func (h *Hub) parsePosition(x, y string) (*utility.PositionHighResolution, error) {
+	px, errX := strconv.ParseFloat(x, 64)
+	py, errY := strconv.ParseFloat(y, 64)
+	if errX != nil || errY != nil {
+		return nil, fmt.Errorf("%q, %q is not a position", x, y)
+	}
+
+	size := h.game.GetGameMap().GetSize()
+	if px < 0 || py < 0 || px >= float64(size.Width) || py >= float64(size.Height) {
+		return nil, fmt.Errorf("%v, %v is outside the %vx%v map", px, py, size.Width, size.Height)
+	}
+
+	return &utility.PositionHighResolution{X: px, Y: py}, nil
+}
+
+// adminList lists connected and link-dead players, and spectators
+func adminList(h *Hub, operator string, args []string, reply func(string)) error {
+	addresses := make(map[*player.Player]string, len(h.clients)+len(h.linkDead))
+	for client, p := range h.clients {
+		addresses[p] = client.conn.RemoteAddr().String()
+	}
+	for _, lingering := range h.linkDead {
+		addresses[lingering.player] = "link-dead"
+	}
+	spectators := make([]string, 0, len(h.spectators))
+	for client := range h.spectators {
+		spectators = append(spectators, fmt.Sprintf("%v (%v)", client.name, client.conn.RemoteAddr().String()))
+	}
+	sort.Strings(spectators)
+
+	h.game.Enqueue(func(g *game.Game) {
+		lines := make([]string, 0, len(addresses)+2)
+		for p, address := range addresses {
+			position := p.GetPosition()
+			lines = append(lines, fmt.Sprintf("%v  id=%v  position=%.1f,%.1f  health=%v  %v", p.GetName(), p.GetID(), position.X, position.Y, p.GetHealth(), address))
+		}
+		sort.Strings(lines)
+
+		lines = append([]string{fmt.Sprintf("%v players", len(addresses))}, lines...)
+		lines = append(lines, fmt.Sprintf("%v spectators: %v", len(spectators), strings.Join(spectators, ", ")))
+		reply(strings.Join(lines, "\n"))
+	})
+
+	return nil
+}
+
+// adminKick disconnects a player's client and removes the player from the game
+func adminKick(h *Hub, operator string, args []string, reply func(string)) error {
+	name := args[0]
+	if client := h.findClient(name); client != nil {
+		h.sendError(client, protobuf.Error_KICKED, "kicked by "+operator)
+		disconnects.With(disconnectKicked).Inc()
+		h.removeClient(client, h.clients[client])
+	} else if lingering, exists := h.linkDead[name]; exists {
+		h.removeLinkDead(name, lingering)
+	} else {
+		return errUnknownPlayer
+	}
+
+	h.game.Enqueue(func(g *game.Game) {
+		reply("kicked " + name)
+	})
+
+	return nil
+}
+
+// adminTeleport moves a player to a position
+func adminTeleport(h *Hub, operator string, args []string, reply func(string)) error {
+	p := h.findPlayer(args[0])
+	if p == nil {
+		return errUnknownPlayer
+	}
+	position, err := h.parsePosition(args[1], args[2])
+	if err != nil {
+		return err
+	}
+
+	h.game.Enqueue(func(g *game.Game) {
+		p.Teleport(position, g)
+		reply(fmt.Sprintf("teleported %v to %.1f,%.1f", p.GetName(), position.X, position.Y))
+	})
+
+	return nil
+}
+
+// adminBroadcast announces a message to everyone in the server chat channel
+func adminBroadcast(h *Hub, operator string, args []string, reply func(string)) error {
+	text := strings.Join(args, " ")
+	h.broadcast(&protobuf.Message{Payload: &protobuf.Message_ChatReceive{ChatReceive: &protobuf.ChatReceive{
+		Channel: protobuf.ChatChannel_SERVER,
+		Text:    text,
+	}}}, nil)
+
+	recipients := len(h.clients) + len(h.spectators)
+	h.game.Enqueue(func(g *game.Game) {
+		reply(fmt.Sprintf("broadcast to %v clients", recipients))
+	})
+
+	return nil
+}
+
+// adminSpawn creates an NPC at a position
+func adminSpawn(h *Hub, operator string, args []string, reply func(string)) error {
+	position, err := h.parsePosition(args[1], args[2])
+	if err != nil {
+		return err
+	}
+	n, err := npc.NewNPC(args[0], h.game.NewEntityID(), position)
+	if err != nil {
+		return fmt.Errorf("%v %q; kinds are %v", err, args[0], strings.Join(npc.Kinds(), ", "))
+	}
+
+	h.game.Enqueue(func(g *game.Game) {
+		g.AddObject(n)
+		reply(fmt.Sprintf("spawned %v id=%v at %.1f,%.1f", n.GetKind(), n.GetID(), position.X, position.Y))
+	})
+
+	return nil
+}
+
+// adminInspect describes a player's state
+func adminInspect(h *Hub, operator string, args []string, reply func(string)) error {
+	p := h.findPlayer(args[0])
+	if p == nil {
+		return errUnknownPlayer
+	}
+
+	connection := "link-dead"
+	if client := h.findClient(args[0]); client != nil {
+		connection = fmt.Sprintf("%v, protocol v%v, welcomed=%v, strikes=%v", client.conn.RemoteAddr().String(), client.protocolVersion, client.welcomed, client.strikes)
+	}
+
+	h.game.Enqueue(func(g *game.Game) {
+		position := p.GetPosition()
+		items := make([]string, 0, len(p.GetInventory()))
+		for _, stack := range p.GetInventory() {
+			items = append(items, fmt.Sprintf("%vx%v", stack.ObjectID, stack.Count))
+		}
+
+		reply(strings.Join([]string{
+			fmt.Sprintf("%v id=%v", p.GetName(), p.GetID()),
+			fmt.Sprintf("connection: %v", connection),
+			fmt.Sprintf("position: %.2f,%.2f facing %.2f", position.X, position.Y, p.GetOrientation()),
+			fmt.Sprintf("health: %v/%v", p.GetHealth(), player.MaxHealth),
+			fmt.Sprintf("inventory: %v", strings.Join(items, ", ")),
+		}, "\n"))
+	})
+
+	return nil
+}
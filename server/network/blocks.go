@@ -0,0 +1,69 @@
+package network
+
+import (
+	"math"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/game"
+	"bitbucket.org/ehhio/ehhworldserver/server/gamemap"
+	"bitbucket.org/ehhio/ehhworldserver/server/protobuf"
+	"bitbucket.org/ehhio/ehhworldserver/server/utility"
+)
+
+// streamBlocks sends the client all map blocks in view around a point that it has not received yet.
+// Points off the map are treated as the nearest point on it.
+func (c *Client) streamBlocks(g *game.Game, position *utility.PositionHighResolution) {
+	gameMap := g.GetGameMap()
+	mapSize, blockSize := gameMap.GetSize(), gameMap.GetBlockSize()
+	x := utility.Clamp(int(utility.ClampHighResolution(position.X, 0, float64(mapSize.Width-1))), 0, mapSize.Width-1)
+	y := utility.Clamp(int(utility.ClampHighResolution(position.Y, 0, float64(mapSize.Height-1))), 0, mapSize.Height-1)
+	blockPosition := gameMap.GetBlockPositionAt(x, y)
+
+	// Explore enough blocks around the view center to cover the view
+	dx := int(math.Ceil(c.viewSize.Width / 2.0 / float64(blockSize.Width)))
+	dy := int(math.Ceil(c.viewSize.Height / 2.0 / float64(blockSize.Height)))
+	minimap := c.explorer()
+	unsent := minimap.ExploreUnknownWithin(blockPosition, dx, dy)
+	if minimap.Explore(blockPosition) {
+		unsent = append(unsent, blockPosition)
+	}
+
+	for _, p := range unsent {
+		block := gameMap.GetBlockAt(p.X*blockSize.Width, p.Y*blockSize.Height)
+		if block == nil {
+			continue
+		}
+
+		c.send(&protobuf.Message{Payload: &protobuf.Message_BlockData{BlockData: newBlockData(block)}})
+	}
+}
+
+// newBlockData encodes a map block and the biome and shape of its cells
+func newBlockData(block *gamemap.Block) *protobuf.BlockData {
+	position := block.GetPosition()
+	size := block.GetSize()
+
+	data := &protobuf.BlockData{
+		X:      int32(position.X),
+		Y:      int32(position.Y),
+		Width:  int32(size.Width),
+		Height: int32(size.Height),
+		Biomes: make([]int32, 0, size.Width*size.Height),
+		Shapes: make([]int32, 0, size.Width*size.Height),
+	}
+
+	for x := 0; x < size.Width; x++ {
+		for y := 0; y < size.Height; y++ {
+			var biome gamemap.Biome
+			var shape gamemap.CellShape
+			if cell := block.GetCellAt(x, y); cell != nil {
+				biome = cell.GetBiome()
+				shape = cell.GetShape()
+			}
+
+			data.Biomes = append(data.Biomes, int32(biome))
+			data.Shapes = append(data.Shapes, int32(shape))
+		}
+	}
+
+	return data
+}
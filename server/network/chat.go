@@ -0,0 +1,159 @@
+package network
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/game"
+	"bitbucket.org/ehhio/ehhworldserver/server/object"
+	"bitbucket.org/ehhio/ehhworldserver/server/player"
+	"bitbucket.org/ehhio/ehhworldserver/server/protobuf"
+	"bitbucket.org/ehhio/ehhworldserver/server/utility"
+)
+
+// Constants
+const (
+	// Longest chat message allowed, in characters
+	maxChatLength = 200
+
+	// Width and height of the area around a player that hears their local chat, in cells
+	localChatRange = 32.0
+)
+
+// ChatFilter cleans up chat text before it is delivered, such as by masking offensive words.
+// Returning an error refuses the message, with the error as the reason.
+type ChatFilter interface {
+	Filter(text string) (string, error)
+}
+
+// WordFilter is a chat filter masking listed words with asterisks, ignoring case
+type WordFilter struct {
+	pattern *regexp.Regexp
+}
+
+// NewWordFilter creates a chat filter masking whole words from a list
+func NewWordFilter(words []string) *WordFilter {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return &WordFilter{}
+	}
+
+	return &WordFilter{pattern: regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)}
+}
+
+// Filter masks listed words in chat text. Never refuses a message.
+func (f *WordFilter) Filter(text string) (string, error) {
+	if f.pattern == nil {
+		return text, nil
+	}
+
+	return f.pattern.ReplaceAllStringFunc(text, func(word string) string {
+		return strings.Repeat("*", utf8.RuneCountInString(word))
+	}), nil
+}
+
+func handleChatSend(ctx *Context) error {
+	c, p := ctx.Client, ctx.Player
+	msg := ctx.Message.GetChatSend()
+
+	text := strings.TrimSpace(msg.Text)
+	if text == "" || !utf8.ValidString(text) {
+		return &HandlerError{Code: protobuf.Error_INVALID, Reason: "chat messages must be text, and not empty"}
+	}
+	if length := utf8.RuneCountInString(text); length > maxChatLength {
+		return &HandlerError{
+			Code:   protobuf.Error_TOO_LARGE,
+			Reason: fmt.Sprintf("chat messages may be at most %v characters, not %v", maxChatLength, length),
+		}
+	}
+	if filter := ctx.Hub.config.ChatFilter; filter != nil {
+		filtered, err := filter.Filter(text)
+		if err != nil {
+			return &HandlerError{Code: protobuf.Error_INVALID, Reason: err.Error()}
+		}
+		text = filtered
+	}
+
+	log.WithFields(log.Fields{
+		"client address": c.conn.RemoteAddr().String(),
+		"name":           p.GetName(),
+		"channel":        msg.Channel,
+		"to":             msg.To,
+		"text":           text,
+	}).Debug("Client chatted")
+
+	receive := &protobuf.ChatReceive{
+		Channel: msg.Channel,
+		Text:    text,
+		From:    p.GetName(),
+		FromId:  p.GetID(),
+	}
+
+	switch msg.Channel {
+	case protobuf.ChatChannel_GLOBAL:
+		ctx.Hub.broadcast(&protobuf.Message{Payload: &protobuf.Message_ChatReceive{ChatReceive: receive}}, nil)
+	case protobuf.ChatChannel_LOCAL:
+		// Who is in range is only known to the game
+		ctx.Hub.game.Enqueue(func(g *game.Game) {
+			ctx.Hub.queueBroadcast(&protobuf.Message{Payload: &protobuf.Message_ChatReceive{ChatReceive: receive}}, playersNear(g, p.GetPosition(), localChatRange))
+		})
+	case protobuf.ChatChannel_WHISPER:
+		target := ctx.Hub.findClient(msg.To)
+		if target == nil {
+			return &HandlerError{
+				Code:   protobuf.Error_NOT_FOUND,
+				Reason: fmt.Sprintf("no player named %v is online", msg.To),
+			}
+		}
+
+		receive.To = msg.To
+		whisper := &protobuf.Message{Payload: &protobuf.Message_ChatReceive{ChatReceive: receive}}
+		ctx.Hub.send(target, whisper)
+		if target != c {
+			ctx.Hub.send(c, whisper)
+		}
+	default:
+		return &HandlerError{
+			Code:   protobuf.Error_UNSUPPORTED,
+			Reason: fmt.Sprintf("unsupported chat channel %v", msg.Channel),
+		}
+	}
+
+	return nil
+}
+
+// playersNear returns the entity IDs of players within a square area centered on a point.
+// Must only be called from the game loop.
+func playersNear(g *game.Game, center *utility.PositionHighResolution, size float64) map[uint32]bool {
+	bottomLeft := &utility.PositionHighResolution{X: center.X - size/2.0, Y: center.Y - size/2.0}
+	objects := g.GetCollision().GetWithinRect(bottomLeft, &utility.SizeHighResolution{Width: size, Height: size}, object.NewTypeFlagsBitSet(object.FlagPlayer))
+
+	players := make(map[uint32]bool, len(objects))
+	for _, o := range objects {
+		if p, ok := o.(*player.Player); ok {
+			players[p.GetID()] = true
+		}
+	}
+
+	return players
+}
+
+// findClient returns the connected client whose player has a name, or nil if there is none
+func (h *Hub) findClient(name string) *Client {
+	for client, p := range h.clients {
+		if p.GetName() == name {
+			return client
+		}
+	}
+
+	return nil
+}
@@ -3,12 +3,16 @@ package network
 // Note: Adapted Heavily from https://github.com/gorilla/websocket/blob/master/examples/chat
 
 import (
-	"encoding/binary"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
-	"github.com/gorilla/websocket"
+	"bitbucket.org/ehhio/ehhworldserver/server/player"
+	"bitbucket.org/ehhio/ehhworldserver/server/player/minimap"
+	"bitbucket.org/ehhio/ehhworldserver/server/protobuf"
+	"bitbucket.org/ehhio/ehhworldserver/server/utility"
+
+	"github.com/golang/protobuf/proto"
 )
 
 // ClientMessage Structure to associate a client with a message
@@ -18,29 +22,109 @@ type ClientMessage struct {
 
 	// The message
 	message []byte
+
+	// For broadcasts, the entity IDs of the players whose clients receive the message. Nil sends it to everyone.
+	recipients map[uint32]bool
+
+	// Payload type of outbound messages, for metrics
+	payload string
 }
 
-// Client Message handler structure around peers and their websocket connection
+// Client Message handler structure around peers and their connection
 type Client struct {
 	// Reference to parent Hub
 	hub *Hub
 
-	// Peer's websocket connection
-	conn *websocket.Conn
+	// Peer's connection, over whichever transport it connected with
+	conn Conn
 
 	// Outbound message channel to peer
 	outbound chan []byte
+
+	// Name of the account the peer logged in with
+	name string
+
+	// The player in the game controlled by the peer, and the character it was restored from
+	player    *player.Player
+	character *characterState
+
+	// Whether the peer only watches, without a player. A spectator's view is centered on its camera,
+	// which follows a player when one is set, and explored tracks the map blocks it has been sent.
+	spectator bool
+	camera    *utility.PositionHighResolution
+	following *player.Player
+	explored  *minimap.Minimap
+
+	// Whether the peer may run admin commands. Admins connect as spectators.
+	admin bool
+
+	// Number of messages from the peer that could not be decoded
+	malformedMessages int
+
+	// Rate limit budgets by message payload type, and strikes for exceeding them.
+	// throttledUntil is read by the inbound goroutine, so must be accessed atomically.
+	buckets        map[string]*tokenBucket
+	strikes        int
+	lastStrike     time.Time
+	throttledUntil int64
+
+	// Handshake state, and the protocol version agreed on
+	welcomed        bool
+	protocolVersion uint32
+
+	// Token the peer may reconnect with, and whether this connection reattached to a lingering player
+	resumeToken string
+	resumed     bool
+
+	// Size of the area around the player replicated to the peer, and the entities currently in it
+	viewSize *utility.SizeHighResolution
+	visible  map[uint32]bool
+
+	// Sequence number of the last input from the peer processed by the game
+	inputSequence uint32
+
+	// Snapshots recently sent to the peer, and the latest one it acknowledged
+	history   *snapshotHistory
+	ackedTick uint32
+	acked     bool
 }
 
-// NewClient constructs an object to represent a remote peer that will communicate with us over a websocket
-func NewClient(hub *Hub, conn *websocket.Conn) *Client {
+// NewClient constructs an object to represent a remote peer that will communicate with us over a connection.
+// name is the name of the account the peer logged in with.
+func NewClient(hub *Hub, conn Conn, name string) *Client {
 	return &Client{
 		hub:      hub,
 		conn:     conn,
-		outbound: make(chan []byte, outboundMessageBuffer)}
+		name:     name,
+		outbound: make(chan []byte, outboundMessageBuffer),
+		viewSize: &utility.SizeHighResolution{Width: hub.config.ViewWidth, Height: hub.config.ViewHeight},
+		visible:  make(map[uint32]bool),
+		history:  newSnapshotHistory(),
+		buckets:  make(map[string]*tokenBucket),
+	}
 }
 
-// outboundHandler pumps messages from the parent hub to the peer websocket connection.
+// send marshals a message and queues it with the parent hub to be sent to the peer.
+// The message is dropped if the hub has stopped, so the game loop never waits on a hub that is gone.
+// Must not be called from the hub goroutine; use Hub.send there.
+func (c *Client) send(msg *protobuf.Message) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"client": c.conn.RemoteAddr().String(),
+			"error":  err,
+		}).Error("Client send; Marshaling error")
+
+		return
+	}
+
+	select {
+	case c.hub.outbound <- &ClientMessage{message: data, client: c, payload: payloadName(msg)}:
+	case <-c.hub.stopped:
+	}
+}
+
+// outboundHandler pumps messages from the parent hub to the peer connection.
 func (c *Client) outboundHandler() {
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
@@ -54,10 +138,8 @@ func (c *Client) outboundHandler() {
 	for {
 		select {
 		case message, ok := <-c.outbound:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
 				// The hub closed our channel and wants us dead
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
 				log.WithFields(log.Fields{
 					"client": c.conn.RemoteAddr().String(),
 				}).Info("Client outbound handler; Channel closed by hub request")
@@ -65,53 +147,33 @@ func (c *Client) outboundHandler() {
 				return
 			}
 
-			w, err := c.conn.NextWriter(websocket.BinaryMessage)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"client": c.conn.RemoteAddr().String(),
-					"error":  err,
-				}).Info("Client outbound handler; Writer open error")
-
-				return
-			}
-
-			// Write message out to client
-			_, err = w.Write(message)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"client": c.conn.RemoteAddr().String(),
-					"error":  err,
-				}).Info("Client outbound handler; Write error")
-
-				return
-			}
-
-			// Add queued messages to the current websocket message
+			// Send queued messages along with this one
+			messages := [][]byte{message}
 			queued := len(c.outbound)
 			for i := 0; i < queued; i++ {
-				_, err := w.Write(<-c.outbound)
-				if err != nil {
-					log.WithFields(log.Fields{
-						"client": c.conn.RemoteAddr().String(),
-						"error":  err,
-					}).Info("Client outbound handler; Write queued message error")
-
-					return
+				message, ok := <-c.outbound
+				if !ok {
+					break
 				}
+				messages = append(messages, message)
 			}
 
-			// Flush write buffer to network layer
-			if err := w.Close(); err != nil {
+			writeBatchSize.Observe(float64(len(messages)))
+			if err := c.conn.WriteMessages(messages); err != nil {
+				writeErrors.Inc()
 				log.WithFields(log.Fields{
 					"client": c.conn.RemoteAddr().String(),
 					"error":  err,
-				}).Info("Client outbound handler; Writer flush/close error")
+				}).Info("Client outbound handler; Write error")
 
 				return
 			}
+
+			// Whatever was queued while writing is how far behind the client is falling
+			outboundQueueDepth.Observe(float64(len(c.outbound)))
 		case <-ticker.C:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if err := c.conn.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
+			if err := c.conn.Ping(); err != nil {
+				writeErrors.Inc()
 				log.WithFields(log.Fields{
 					"client": c.conn.RemoteAddr().String(),
 					"error":  err,
@@ -123,14 +185,18 @@ func (c *Client) outboundHandler() {
 	}
 }
 
-// inboundHandler Pumps messages from the peer websocket connection to the parent hub.
+// inboundHandler Pumps messages from the peer connection to the parent hub.
+// Stops reading once the hub has stopped, as nothing would handle the messages.
 func (c *Client) inboundHandler() {
 	defer func() {
 		log.WithFields(log.Fields{
 			"client": c.conn.RemoteAddr().String(),
 		}).Info("Client inbound handler stopping")
 
-		c.hub.unregister <- c
+		select {
+		case c.hub.unregister <- c:
+		case <-c.hub.stopped:
+		}
 		c.conn.Close()
 	}()
 
@@ -138,12 +204,8 @@ func (c *Client) inboundHandler() {
 		"client": c.conn.RemoteAddr().String(),
 	}).Info("Client Inbound Handler Started.")
 
-	c.conn.SetReadLimit(maxMessageSize)
-	c.conn.SetReadDeadline(time.Now().Add(pongWait))
-	c.conn.SetPongHandler(func(string) error { c.conn.SetReadDeadline(time.Now().Add(pongWait)); return nil })
-
 	for {
-		messageType, message, err := c.conn.ReadMessage()
+		message, err := c.conn.ReadMessage()
 		if err != nil {
 			log.WithFields(log.Fields{
 				"client": c.conn.RemoteAddr().String(),
@@ -155,75 +217,18 @@ func (c *Client) inboundHandler() {
 
 		log.WithFields(log.Fields{
 			"client":  c.conn.RemoteAddr().String(),
-			"type":    messageType,
 			"message": message,
-		}).Info("Client Sent Message.")
+		}).Debug("Client Sent Message.")
 
-		// Only support binary messages
-		if messageType != websocket.BinaryMessage {
+		// Drop everything from flooding clients before it reaches the hub
+		if c.isThrottled(time.Now()) {
 			continue
 		}
 
-		log.WithFields(log.Fields{
-			"client":  c.conn.RemoteAddr().String(),
-			"type":    messageType,
-			"message": message,
-		}).Info("Client Sent Message 2.")
-
-		// Handle blank or too short messages
-		offset := 0
-		totalMessageLength := len(message)
-
-		log.WithFields(log.Fields{
-			"client":  c.conn.RemoteAddr().String(),
-			"type":    messageType,
-			"message": message,
-			"length":  totalMessageLength,
-		}).Info("Client Sent Message 3.")
-
-		if totalMessageLength == 0 || totalMessageLength <= offset+packedMessageHeaderSize {
-			continue
-		}
-
-		// Extract messages and pipe upstream to the hub
-		for offset < totalMessageLength {
-			// Header read bounds check
-			if offset+packedMessageHeaderSize >= totalMessageLength {
-				break
-			}
-
-			// Determine location
-			msglen := int(binary.BigEndian.Uint16(message[offset : offset+packedMessageHeaderSize]))
-
-			log.WithFields(log.Fields{
-				"client":                  c.conn.RemoteAddr().String(),
-				"type":                    messageType,
-				"message":                 message,
-				"msglen":                  msglen,
-				"offset":                  offset,
-				"packedMessageHeaderSize": packedMessageHeaderSize,
-				"data":    message[offset : offset+packedMessageHeaderSize],
-				"parsed":  binary.BigEndian.Uint16(message[offset : offset+packedMessageHeaderSize]),
-				"parsed2": binary.LittleEndian.Uint16(message[offset : offset+packedMessageHeaderSize]),
-			}).Info("Client Sent Message 4.")
-
-			// Payload read bounds check
-			if msglen == 0 || offset+packedMessageHeaderSize+msglen > totalMessageLength {
-				break
-			}
-
-			// Extract
-			payload := message[offset+packedMessageHeaderSize : offset+packedMessageHeaderSize+msglen]
-
-			log.WithFields(log.Fields{
-				"payload": payload,
-			}).Info("Client Sent Message 5. piping!")
-
-			// Pipe
-			c.hub.inbound <- &ClientMessage{message: payload, client: c}
-
-			// Update offset
-			offset += packedMessageHeaderSize + msglen
+		select {
+		case c.hub.inbound <- &ClientMessage{message: message, client: c}:
+		case <-c.hub.stopped:
+			return
 		}
 	}
 }
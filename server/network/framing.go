@@ -0,0 +1,136 @@
+package network
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Constants
+const (
+	// Websocket subprotocols clients offer to pick a framing. Clients offering none get framing v1.
+	subprotocolFramingV1 = "ehhworld.v1"
+	subprotocolFramingV2 = "ehhworld.v2"
+
+	// Largest websocket message accepted from a peer using framing v2, in bytes
+	maxMessageSizeV2 = 64 * 1024
+
+	// Largest single client message of a payload type without its own limit, in bytes
+	defaultMaxPayloadSize = 256
+)
+
+// Errors
+var (
+	// errFrameTooLarge is returned when a message is too large for a framing to carry
+	errFrameTooLarge = errors.New("message too large for framing")
+
+	// errFrameTruncated is returned when a framed message is cut short
+	errFrameTruncated = errors.New("framed message truncated")
+)
+
+// subprotocols are the framing subprotocols the server speaks, in order of preference
+var subprotocols = []string{subprotocolFramingV2, subprotocolFramingV1}
+
+// maxPayloadSizes is the largest single client message allowed of each payload type, in bytes
+var maxPayloadSizes = map[string]int{
+	"Hello":        160,
+	"Move":         64,
+	"Attack":       64,
+	"Build":        64,
+	"Sleep":        32,
+	"SnapshotAck":  32,
+	"ChatSend":     4*maxChatLength + 64,
+	"AdminCommand": 1024,
+}
+
+// framing is how messages are packed into websocket messages, each prefixed by its length.
+// Several messages may be packed into one websocket message.
+type framing int
+
+// Framings
+const (
+	// framingV1 prefixes messages with a 2 byte big-endian length, so messages are at most 65535 bytes
+	framingV1 framing = 1
+
+	// framingV2 prefixes messages with a varint length
+	framingV2 framing = 2
+)
+
+// framingForSubprotocol returns the framing negotiated by a websocket subprotocol
+func framingForSubprotocol(subprotocol string) framing {
+	if subprotocol == subprotocolFramingV2 {
+		return framingV2
+	}
+
+	return framingV1
+}
+
+func (f framing) String() string {
+	return fmt.Sprintf("v%d", int(f))
+}
+
+// readLimit returns the largest websocket message accepted from a peer using the framing
+func (f framing) readLimit() int64 {
+	if f == framingV2 {
+		return maxMessageSizeV2
+	}
+
+	return maxMessageSize
+}
+
+// pack prefixes a message with its length
+func (f framing) pack(msg []byte) ([]byte, error) {
+	if f == framingV2 {
+		header := make([]byte, binary.MaxVarintLen64)
+		n := binary.PutUvarint(header, uint64(len(msg)))
+		return append(header[:n], msg...), nil
+	}
+
+	if len(msg) > math.MaxUint16 {
+		return nil, errFrameTooLarge
+	}
+
+	header := make([]byte, packedMessageHeaderSize)
+	binary.BigEndian.PutUint16(header, uint16(len(msg)))
+	return append(header, msg...), nil
+}
+
+// unpack splits a websocket message into the messages packed into it.
+// Returns the messages unpacked before any error.
+func (f framing) unpack(data []byte) ([][]byte, error) {
+	messages := make([][]byte, 0, 1)
+	for len(data) > 0 {
+		var length, header int
+		if f == framingV2 {
+			value, n := binary.Uvarint(data)
+			if n <= 0 || value > uint64(len(data)) {
+				return messages, errFrameTruncated
+			}
+			length, header = int(value), n
+		} else {
+			if len(data) < packedMessageHeaderSize {
+				return messages, errFrameTruncated
+			}
+			length, header = int(binary.BigEndian.Uint16(data)), packedMessageHeaderSize
+		}
+
+		if length == 0 || header+length > len(data) {
+			return messages, errFrameTruncated
+		}
+
+		messages = append(messages, data[header:header+length])
+		data = data[header+length:]
+	}
+
+	return messages, nil
+}
+
+// maxPayloadSize returns the largest single client message allowed of a payload type, in bytes
+func maxPayloadSize(payload string) int {
+	if size, exists := maxPayloadSizes[payload]; exists {
+		return size
+	}
+
+	return defaultMaxPayloadSize
+}
@@ -0,0 +1,174 @@
+package network
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/game"
+	"bitbucket.org/ehhio/ehhworldserver/server/protobuf"
+	"bitbucket.org/ehhio/ehhworldserver/server/utility"
+)
+
+// registerHandlers registers the handlers for the core gameplay messages
+func (h *Hub) registerHandlers() {
+	h.Handle((*protobuf.Message_Hello)(nil), handleHello)
+	h.Handle((*protobuf.Message_Move)(nil), handleMove)
+	h.Handle((*protobuf.Message_Attack)(nil), handleAttack)
+	h.Handle((*protobuf.Message_Build)(nil), handleBuild)
+	h.Handle((*protobuf.Message_Sleep)(nil), handleSleep)
+	h.Handle((*protobuf.Message_SnapshotAck)(nil), handleSnapshotAck)
+	h.Handle((*protobuf.Message_Spectate)(nil), handleSpectate)
+	h.Handle((*protobuf.Message_ChatSend)(nil), handleChatSend)
+	h.Handle((*protobuf.Message_AdminCommand)(nil), handleAdminCommand)
+}
+
+func handleHello(ctx *Context) error {
+	c, p := ctx.Client, ctx.Player
+	version := ctx.Message.GetHello().ProtocolVersion
+
+	log.WithFields(log.Fields{
+		"client address":   c.conn.RemoteAddr().String(),
+		"protocol version": version,
+	}).Info("Client Hello")
+
+	if c.welcomed {
+		return nil
+	}
+
+	// Reject clients we can't speak to, then hang up
+	if version < MinProtocolVersion {
+		ctx.Hub.send(c, &protobuf.Message{Payload: &protobuf.Message_Reject{Reject: &protobuf.Reject{
+			Reason:          fmt.Sprintf("protocol version %v is no longer supported; update to version %v", version, ProtocolVersion),
+			ProtocolVersion: ProtocolVersion,
+		}}})
+
+		log.WithFields(log.Fields{
+			"client address": c.conn.RemoteAddr().String(),
+		}).Info("Client Disconnected; Unsupported protocol version")
+
+		disconnects.With(disconnectUnsupportedVersion).Inc()
+		ctx.Hub.removeClient(c, p)
+		return nil
+	}
+
+	// Speak the newest version we both know
+	if version > ProtocolVersion {
+		version = ProtocolVersion
+	}
+	c.protocolVersion = version
+	c.welcomed = true
+
+	// Let the client reconnect to its player if the connection drops. Spectators have no player to come back to.
+	if !c.spectator {
+		token, err := ctx.Hub.resumes.Start(c.name)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"client address": c.conn.RemoteAddr().String(),
+				"error":          err,
+			}).Error("Failed to start resume token")
+		}
+		c.resumeToken = token
+	}
+	token, resumed := c.resumeToken, c.resumed
+
+	var entityID uint32
+	var playerName string
+	if p != nil {
+		entityID, playerName = p.GetID(), p.GetName()
+	}
+
+	ctx.Hub.game.Enqueue(func(g *game.Game) {
+		gameMap := g.GetGameMap()
+		c.send(&protobuf.Message{Payload: &protobuf.Message_Welcome{Welcome: &protobuf.Welcome{
+			ProtocolVersion: version,
+			EntityId:        entityID,
+			PlayerName:      playerName,
+			MapWidth:        int32(gameMap.GetSize().Width),
+			MapHeight:       int32(gameMap.GetSize().Height),
+			BlockWidth:      int32(gameMap.GetBlockSize().Width),
+			BlockHeight:     int32(gameMap.GetBlockSize().Height),
+			Seed:            gameMap.GetSeed(),
+			TickRate:        int32(g.GetTickRate()),
+			SendRate:        int32(g.GetSendRate()),
+			ResumeToken:     token,
+			Resumed:         resumed,
+			Spectating:      c.spectator,
+		}}})
+
+		// Start replicating the game to the client
+		g.AddViewer(c)
+	})
+
+	return nil
+}
+
+func handleMove(ctx *Context) error {
+	c, p := ctx.Client, ctx.Player
+	msg := ctx.Message.GetMove()
+
+	log.WithFields(log.Fields{
+		"client address": c.conn.RemoteAddr().String(),
+		"direction":      msg.Direction,
+	}).Debug("Client Moved")
+
+	direction := utility.NewVectorFromDirection(msg.Direction)
+	ctx.Hub.game.Enqueue(func(g *game.Game) {
+		p.SetMoveDirection(direction)
+		c.processedInput(msg.Sequence)
+	})
+
+	return nil
+}
+
+func handleAttack(ctx *Context) error {
+	c := ctx.Client
+	msg := ctx.Message.GetAttack()
+
+	log.WithFields(log.Fields{
+		"client address": c.conn.RemoteAddr().String(),
+		"target":         msg.Target,
+	}).Info("Client Attacked")
+
+	ctx.Hub.game.Enqueue(func(g *game.Game) {
+		c.processedInput(msg.Sequence)
+	})
+
+	return nil
+}
+
+func handleBuild(ctx *Context) error {
+	c := ctx.Client
+	msg := ctx.Message.GetBuild()
+
+	log.WithFields(log.Fields{
+		"client address": c.conn.RemoteAddr().String(),
+		"type":           msg.Type,
+	}).Info("Client Built")
+
+	ctx.Hub.game.Enqueue(func(g *game.Game) {
+		c.processedInput(msg.Sequence)
+	})
+
+	return nil
+}
+
+func handleSleep(ctx *Context) error {
+	log.WithFields(log.Fields{
+		"client address": ctx.Client.conn.RemoteAddr().String(),
+		"duration":       ctx.Message.GetSleep().Duration,
+	}).Info("Client Slept")
+
+	return nil
+}
+
+func handleSnapshotAck(ctx *Context) error {
+	c := ctx.Client
+	tick := ctx.Message.GetSnapshotAck().Tick
+
+	ctx.Hub.game.Enqueue(func(g *game.Game) {
+		c.acknowledge(tick)
+	})
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package network
+
+import (
+	"bitbucket.org/ehhio/ehhworldserver/server/protobuf"
+)
+
+// Constants
+const (
+	// Number of snapshots remembered per client to encode deltas against
+	snapshotHistorySize = 32
+)
+
+// snapshotRecord is the full entity state of a snapshot that was sent to a client
+type snapshotRecord struct {
+	tick   uint32
+	states map[uint32]*protobuf.EntityState
+}
+
+// snapshotHistory is a fixed size ring buffer of the most recent snapshots sent to a client
+type snapshotHistory struct {
+	records [snapshotHistorySize]*snapshotRecord
+	next    int
+}
+
+// newSnapshotHistory creates an empty snapshot history
+func newSnapshotHistory() *snapshotHistory {
+	return &snapshotHistory{}
+}
+
+// add records a sent snapshot, replacing the oldest record when full
+func (h *snapshotHistory) add(tick uint32, states map[uint32]*protobuf.EntityState) {
+	h.records[h.next] = &snapshotRecord{tick: tick, states: states}
+	h.next = (h.next + 1) % snapshotHistorySize
+}
+
+// get returns the recorded snapshot for a tick, or nil if it is no longer remembered
+func (h *snapshotHistory) get(tick uint32) *snapshotRecord {
+	for _, record := range h.records {
+		if record != nil && record.tick == tick {
+			return record
+		}
+	}
+
+	return nil
+}
@@ -1,30 +1,50 @@
 package network
 
 import (
-	"encoding/binary"
+	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
-	"bitbucket.org/ehhio/ehhworldserver/server/utility"
-
+	"bitbucket.org/ehhio/ehhworldserver/server/account"
 	"bitbucket.org/ehhio/ehhworldserver/server/game"
 	"bitbucket.org/ehhio/ehhworldserver/server/player"
 	"bitbucket.org/ehhio/ehhworldserver/server/protobuf"
+	"bitbucket.org/ehhio/ehhworldserver/server/store"
 
 	"github.com/golang/protobuf/proto"
 )
 
-// Hub is a networking message manager between clients and the game, using websockets.
+// Hub is a networking message manager between clients and the game, whichever transport they connect over.
 type Hub struct {
 	// Serving is true if the hub is serving clients
 	serving bool
 
+	// Closed to stop serving, and closed once stopped
+	quit    chan struct{}
+	stopped chan struct{}
+
+	// Settings used to serve clients
+	config *Config
+
+	// Transports peers connect over, such as websockets and plain TCP
+	transports []Transport
+
 	// Game object the hub will pipe data to
 	game *game.Game
 
 	// Clients maps network clients to players in the connected game
 	clients map[*Client]*player.Player
 
+	// Spectators watching the game without a player
+	spectators map[*Client]bool
+
+	// Players whose clients lost their connection, lingering for them to reconnect, by account name
+	linkDead map[string]*linkDeadPlayer
+
+	// Resume tokens clients may reconnect with, instead of logging in again
+	resumes *account.Sessions
+
 	// Inbound messages channel from Clients
 	inbound chan *ClientMessage
 
@@ -36,40 +56,99 @@ type Hub struct {
 
 	// Unregister requests channel from existing Clients
 	unregister chan *Client
+
+	// Admin commands to run, from the local console
+	adminRequests chan *adminRequest
+
+	// Functions reading hub state for other goroutines, such as status API requests
+	queries chan func()
+
+	// Handlers for inbound messages
+	registry *Registry
+
+	// Saves and loads player characters
+	persistence *persistence
 }
 
-// NewHub constructs a websocket Hub to manage clients and messages to and from them
-func NewHub(game *game.Game) *Hub {
-	return &Hub{
-		clients:    make(map[*Client]*player.Player),
-		inbound:    make(chan *ClientMessage),
-		outbound:   make(chan *ClientMessage),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
-		game:       game,
+// NewHub constructs a Hub to manage clients and messages to and from them
+func NewHub(config *Config, game *game.Game) *Hub {
+	h := &Hub{
+		config:        config,
+		quit:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+		clients:       make(map[*Client]*player.Player),
+		spectators:    make(map[*Client]bool),
+		linkDead:      make(map[string]*linkDeadPlayer),
+		resumes:       account.NewSessions(resumeTokenLifetime),
+		inbound:       make(chan *ClientMessage),
+		outbound:      make(chan *ClientMessage, hubOutboundBuffer),
+		register:      make(chan *Client),
+		unregister:    make(chan *Client),
+		adminRequests: make(chan *adminRequest),
+		queries:       make(chan func()),
+		registry:      NewRegistry(),
+		persistence:   newPersistence(config.Store),
+		game:          game,
 	}
+
+	h.registry.Use(LoggingMiddleware, RateLimitMiddleware, HandshakeMiddleware, SpectatorMiddleware)
+	h.registerHandlers()
+
+	return h
 }
 
-// Start starts the serving loop for the hub to handle websocket messages from clients
+// Start starts the serving loop for the hub to handle messages from clients
 func (h *Hub) Start() {
 	defer func() {
 		log.Printf("Hub is stopping.")
+		close(h.stopped)
 	}()
 
 	h.serving = true
+	go h.persistence.start()
+
+	// Periodically save players, so little progress is lost if the server crashes
+	var saveTick <-chan time.Time
+	if h.config.SaveInterval > 0 {
+		saveTicker := time.NewTicker(h.config.SaveInterval)
+		defer saveTicker.Stop()
+		saveTick = saveTicker.C
+	}
+
+	sweepTicker := time.NewTicker(linkDeadSweepPeriod)
+	defer sweepTicker.Stop()
+
+	queueTicker := time.NewTicker(outboundQueueSamplePeriod)
+	defer queueTicker.Stop()
 
 	// Core serving loop
 	for h.serving {
 		select {
+		case <-h.quit:
+			h.serving = false
+		case <-saveTick:
+			timeEvent("save", h.saveClients)
+		case <-sweepTicker.C:
+			timeEvent("sweep", h.expireLinkDead)
+		case <-queueTicker.C:
+			timeEvent("queues", h.sampleOutboundQueues)
 		case client := <-h.register:
-			h.handleClientConnect(client)
+			timeEvent("register", func() { h.handleClientConnect(client) })
 		case client := <-h.unregister:
-			h.handleClientDisconnect(client)
+			timeEvent("unregister", func() { h.handleClientDisconnect(client) })
 		case message := <-h.inbound:
-			h.handleInboundMessage(message)
+			timeEvent("inbound", func() { h.handleInboundMessage(message) })
 		case message := <-h.outbound:
-			h.handleOutboundMessage(message)
+			timeEvent("outbound", func() { h.handleOutboundMessage(message) })
+		case request := <-h.adminRequests:
+			timeEvent("admin", func() { h.handleAdminRequest(request) })
+		case query := <-h.queries:
+			timeEvent("query", query)
 		}
+
+		connectedClients.Set(float64(len(h.clients)))
+		connectedSpectators.Set(float64(len(h.spectators)))
+		linkDeadPlayers.Set(float64(len(h.linkDead)))
 	}
 
 	// Drop all players
@@ -78,121 +157,328 @@ func (h *Hub) Start() {
 			"client address": client.conn.RemoteAddr().String(),
 		}).Info("Client Disconnected; Hub is stopping")
 
-		h.game.RemoveObject(player)
-		delete(h.clients, client)
-		close(client.outbound)
+		disconnects.With(disconnectShutdown).Inc()
+		h.removeClient(client, player)
+	}
+	for client := range h.spectators {
+		disconnects.With(disconnectShutdown).Inc()
+		h.removeSpectator(client)
+	}
+	for name, lingering := range h.linkDead {
+		h.removeLinkDead(name, lingering)
 	}
 }
 
-// Stop stops the hub serving clients
+// Handle registers the handler for a client message payload type, such as (*protobuf.Message_Move)(nil).
+// Must be called before the hub is started.
+func (h *Hub) Handle(payload interface{}, handler Handler) {
+	h.registry.Handle(payload, handler)
+}
+
+// Use adds middleware around every client message handler.
+// Must be called before the hub is started.
+func (h *Hub) Use(middleware ...Middleware) {
+	h.registry.Use(middleware...)
+}
+
+// Stop stops the hub serving clients, waiting until all clients are dropped.
+// Their players are saved once the game runs its queued commands.
 func (h *Hub) Stop() {
 	log.Info("Stopping webserver")
-	h.serving = false
+	h.closeAll()
+	close(h.quit)
+	<-h.stopped
+}
+
+// Flush writes all saved player state to the store, waiting until done.
+// Call after the game has stopped, so no more saves are queued.
+func (h *Hub) Flush() {
+	h.persistence.flush()
 }
 
 func (h *Hub) handleClientConnect(client *Client) {
 	log.WithFields(log.Fields{
 		"client address": client.conn.RemoteAddr().String(),
+		"name":           client.name,
 	}).Info("New Client Connectedddd")
 
-	player := player.NewPlayer(utility.GeneratePlayerName(), h.game.GetGameMap().RandomPositionHighResolution(), h.game.GetGameMap().GetBlocksSize())
-	h.game.AddObject(player)
+	// Spectators only watch, so never get a player
+	if client.spectator {
+		h.spectators[client] = true
+		return
+	}
+
+	// Pick up a player left behind by a dropped connection
+	if h.reattach(client) {
+		log.WithFields(log.Fields{
+			"client address": client.conn.RemoteAddr().String(),
+			"name":           client.name,
+		}).Info("Client reattached to link-dead player")
+
+		return
+	}
+
+	// An account may only be connected once; the newest connection takes over the player
+	for other, otherPlayer := range h.clients {
+		if other.name == client.name {
+			log.WithFields(log.Fields{
+				"client address": other.conn.RemoteAddr().String(),
+				"name":           other.name,
+			}).Info("Client Disconnected; Logged in elsewhere")
+
+			disconnects.With(disconnectReplaced).Inc()
+			h.transferPlayer(other, client, otherPlayer)
+			return
+		}
+	}
+
+	player := client.character.newPlayer(h.game.NewEntityID(), h.game.GetGameMap())
 	h.clients[client] = player
+	client.player = player
+	h.game.Enqueue(func(g *game.Game) {
+		g.AddObject(player)
+	})
 }
 
 func (h *Hub) handleClientDisconnect(client *Client) {
+	if h.spectators[client] {
+		log.WithFields(log.Fields{
+			"client address": client.conn.RemoteAddr().String(),
+		}).Info("Spectator Disconnected; Connection lost")
+
+		disconnects.With(disconnectReadError).Inc()
+		h.removeSpectator(client)
+		return
+	}
+
 	if player, exists := h.clients[client]; exists {
 		log.WithFields(log.Fields{
 			"client address": client.conn.RemoteAddr().String(),
-		}).Info("Client Disconnected; Own volition")
+		}).Info("Client Disconnected; Connection lost")
+
+		disconnects.With(disconnectReadError).Inc()
+		h.linger(client, player)
+	}
+}
+
+// removeClient drops a client and its player from the hub and the game, and closes the client's outbound channel.
+// Does nothing if the client was already removed.
+func (h *Hub) removeClient(client *Client, player *player.Player) {
+	if client.spectator {
+		h.removeSpectator(client)
+		return
+	}
+	if _, exists := h.clients[client]; !exists {
+		return
+	}
+
+	delete(h.clients, client)
+	close(client.outbound)
+	h.resumes.End(client.resumeToken)
+	character := client.character.character
+	h.game.Enqueue(func(g *game.Game) {
+		g.RemoveViewer(client)
+		g.RemoveObject(player)
+		h.persistence.save(captureState(character, player))
+	})
+}
+
+// transferPlayer hands the player of a client over to a new client for the same account, and drops the old client.
+// The player stays in the game, so no state is lost in between.
+func (h *Hub) transferPlayer(from, to *Client, player *player.Player) {
+	delete(h.clients, from)
+	close(from.outbound)
+	h.resumes.End(from.resumeToken)
+	h.game.Enqueue(func(g *game.Game) {
+		g.RemoveViewer(from)
+		player.GetMinimap().ResetExplored()
+	})
+
+	h.clients[to] = player
+	to.player = player
+	to.character = from.character
+}
 
-		h.game.RemoveObject(player)
-		delete(h.clients, client)
-		close(client.outbound)
+// saveClients queues the state of every connected and link-dead player to be saved
+func (h *Hub) saveClients() {
+	players := make(map[*store.Character]*player.Player, len(h.clients)+len(h.linkDead))
+	for client, player := range h.clients {
+		players[client.character.character] = player
+	}
+	for _, lingering := range h.linkDead {
+		players[lingering.client.character.character] = lingering.player
 	}
+
+	h.game.Enqueue(func(g *game.Game) {
+		for character, player := range players {
+			h.persistence.save(captureState(character, player))
+		}
+	})
 }
 
 func (h *Hub) handleInboundMessage(msg *ClientMessage) {
-	log.WithFields(log.Fields{
-		"msg": msg,
-	}).Info("Spawning Process Message")
-	go processMessage(msg)
+	player, exists := h.clients[msg.client]
+	if !exists && !h.spectators[msg.client] {
+		return
+	}
+
+	h.processMessage(msg, player)
 }
 
 func (h *Hub) handleOutboundMessage(msg *ClientMessage) {
 	if msg.client != nil {
 		// Message a client
-		if player, exists := h.clients[msg.client]; exists {
-			select {
-			case msg.client.outbound <- packMessage(msg.message):
-			default:
-				log.WithFields(log.Fields{
-					"client address": msg.client.conn.RemoteAddr().String(),
-				}).Info("Client Disconnected; Buffer full")
-
-				h.game.RemoveObject(player)
-				delete(h.clients, msg.client)
-				close(msg.client.outbound)
-			}
+		if player, exists := h.clients[msg.client]; exists || h.spectators[msg.client] {
+			h.deliver(msg.client, player, msg)
 		}
 	} else {
-		// Broadcast message
+		// Broadcast message, to everyone or only to the players it is meant for
 		for client, player := range h.clients {
-			select {
-			case client.outbound <- packMessage(msg.message):
-			default:
-				log.WithFields(log.Fields{
-					"client address": client.conn.RemoteAddr().String(),
-				}).Info("Client Disconnected; Buffer full")
-
-				h.game.RemoveObject(player)
-				delete(h.clients, client)
-				close(client.outbound)
+			if msg.recipients == nil || msg.recipients[player.GetID()] {
+				h.deliver(client, player, msg)
+			}
+		}
+		if msg.recipients == nil {
+			for client := range h.spectators {
+				h.deliver(client, nil, msg)
 			}
 		}
 	}
 }
 
-func packMessage(msg []byte) []byte {
-	// Build a header for the outgoing message
-	messageHeader := make([]byte, packedMessageHeaderSize)
-	binary.BigEndian.PutUint16(messageHeader, uint16(len(msg)))
-	return append(messageHeader, msg...)
+// deliver queues a marshaled message on a client's outbound channel.
+// Clients that can't keep up are dropped.
+func (h *Hub) deliver(client *Client, player *player.Player, msg *ClientMessage) {
+	select {
+	case client.outbound <- msg.message:
+		messagesOut.With(msg.payload).Inc()
+		bytesOut.With(msg.payload).Add(float64(len(msg.message)))
+	default:
+		log.WithFields(log.Fields{
+			"client address": client.conn.RemoteAddr().String(),
+		}).Info("Client Disconnected; Buffer full")
+
+		disconnects.With(disconnectBufferFull).Inc()
+		h.removeClient(client, player)
+	}
+}
+
+// send marshals a message and sends it to a client.
+// Only for use from the hub goroutine; other goroutines must use Client.send.
+func (h *Hub) send(client *Client, msg *protobuf.Message) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"client address": client.conn.RemoteAddr().String(),
+			"error":          err,
+		}).Error("Hub send; Marshaling error")
+
+		return
+	}
+
+	h.handleOutboundMessage(&ClientMessage{message: data, client: client, payload: payloadName(msg)})
+}
+
+// broadcast marshals a message and sends it to every client, or only to the players with entity IDs in recipients.
+// Only for use from the hub goroutine; other goroutines must use Hub.queueBroadcast.
+func (h *Hub) broadcast(msg *protobuf.Message, recipients map[uint32]bool) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Hub broadcast; Marshaling error")
+
+		return
+	}
+
+	h.handleOutboundMessage(&ClientMessage{message: data, recipients: recipients, payload: payloadName(msg)})
+}
+
+// queueBroadcast marshals a message and queues it with the hub to be broadcast, as Hub.broadcast does.
+// The message is dropped if the hub has stopped.
+// Must not be called from the hub goroutine; use Hub.broadcast there.
+func (h *Hub) queueBroadcast(msg *protobuf.Message, recipients map[uint32]bool) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Hub broadcast; Marshaling error")
+
+		return
+	}
+
+	select {
+	case h.outbound <- &ClientMessage{message: data, recipients: recipients, payload: payloadName(msg)}:
+	case <-h.stopped:
+	}
 }
 
-func processMessage(message *ClientMessage) {
+// processMessage decodes a client message and dispatches it to the handler registered for its payload.
+// Runs on the hub goroutine, so handlers must queue any game state changes as game commands.
+func (h *Hub) processMessage(message *ClientMessage, player *player.Player) {
 	log.WithFields(log.Fields{
 		"message": message,
-	}).Info("Process Message")
+	}).Debug("Process Message")
 
 	// Decode message
 	wrapper := &protobuf.Message{}
 	err := proto.Unmarshal(message.message, wrapper)
 	if err != nil {
-		log.Fatal("Unmarshaling: ", err)
+		messagesIn.With("Malformed").Inc()
+		bytesIn.With("Malformed").Add(float64(len(message.message)))
+		h.handleMalformedMessage(message.client, player, protobuf.Error_MALFORMED, err.Error())
+		return
 	}
 
-	// Process payload
-	switch msg := wrapper.Payload.(type) {
-	case *protobuf.Message_Move:
-		handleMove(msg, message.client)
-	case *protobuf.Message_Attack:
-		handleAttack(msg, message.client)
+	// Refuse messages larger than their payload type allows
+	payload := payloadName(wrapper)
+	messagesIn.With(payload).Inc()
+	bytesIn.With(payload).Add(float64(len(message.message)))
+	if max := maxPayloadSize(payload); len(message.message) > max {
+		h.handleMalformedMessage(message.client, player, protobuf.Error_TOO_LARGE, fmt.Sprintf("%v messages may be at most %v bytes", payload, max))
+		return
+	}
+
+	// Handle payload
+	err = h.registry.Dispatch(&Context{Hub: h, Client: message.client, Player: player, Message: wrapper})
+	if err != nil {
+		if handlerErr, ok := err.(*HandlerError); ok {
+			h.sendError(message.client, handlerErr.Code, handlerErr.Reason)
+		} else {
+			h.sendError(message.client, protobuf.Error_UNKNOWN, err.Error())
+		}
 	}
 }
 
-func handleMove(msg *protobuf.Message_Move, c *Client) {
-	log.WithFields(log.Fields{
-		"client address": c.conn.RemoteAddr().String(),
-		"direction":      msg.Move.Direction,
-	}).Info("Client Moved")
+// sendError replies to a client with a protocol error
+func (h *Hub) sendError(client *Client, code protobuf.Error_Code, reason string) {
+	h.send(client, &protobuf.Message{Payload: &protobuf.Message_Error{Error: &protobuf.Error{
+		Code:   code,
+		Reason: reason,
+	}}})
 }
 
-func handleAttack(msg *protobuf.Message_Attack, c *Client) {
+// handleMalformedMessage replies to a client that sent an undecodable or oversized message, and disconnects repeat offenders
+func (h *Hub) handleMalformedMessage(client *Client, player *player.Player, code protobuf.Error_Code, reason string) {
+	client.malformedMessages++
+
 	log.WithFields(log.Fields{
-		"client address": c.conn.RemoteAddr().String(),
-		"direction":      msg.Attack.Target,
-	}).Info("Client Attacked")
+		"client address": client.conn.RemoteAddr().String(),
+		"code":           code,
+		"error":          reason,
+		"count":          client.malformedMessages,
+	}).Warn("Client sent malformed message")
+
+	h.sendError(client, code, reason)
+
+	if client.malformedMessages >= maxMalformedMessages {
+		log.WithFields(log.Fields{
+			"client address": client.conn.RemoteAddr().String(),
+		}).Info("Client Disconnected; Too many malformed messages")
+
+		disconnects.With(disconnectMalformed).Inc()
+		h.removeClient(client, player)
+	}
 }
 
 // test := &websocket.Message{
@@ -0,0 +1,92 @@
+package network
+
+import (
+	"time"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/metrics"
+)
+
+// Constants
+const (
+	// How often the hub samples how far behind the slowest clients are
+	outboundQueueSamplePeriod = time.Second
+)
+
+// Disconnect reasons, as recorded by the disconnects metric
+const (
+	disconnectReadError          = "read_error"
+	disconnectBufferFull         = "buffer_full"
+	disconnectKicked             = "kicked"
+	disconnectMalformed          = "malformed"
+	disconnectRateLimited        = "rate_limited"
+	disconnectUnsupportedVersion = "unsupported_version"
+	disconnectReplaced           = "replaced"
+	disconnectShutdown           = "shutdown"
+)
+
+// Metrics about the hub and its clients
+var (
+	messagesIn = metrics.NewCounterVec("ehhworld_network_messages_received_total",
+		"Messages received from clients, by payload type.", "payload")
+	bytesIn = metrics.NewCounterVec("ehhworld_network_received_bytes_total",
+		"Bytes of messages received from clients, by payload type, excluding framing.", "payload")
+	messagesOut = metrics.NewCounterVec("ehhworld_network_messages_sent_total",
+		"Messages queued to clients, by payload type. Broadcasts count once per recipient.", "payload")
+	bytesOut = metrics.NewCounterVec("ehhworld_network_sent_bytes_total",
+		"Bytes of messages queued to clients, by payload type, excluding framing.", "payload")
+	disconnects = metrics.NewCounterVec("ehhworld_network_disconnects_total",
+		"Clients disconnected, by reason.", "reason")
+
+	hubEvents = metrics.NewHistogramVec("ehhworld_hub_event_duration_seconds",
+		"Time taken by the hub to handle each event, by event.",
+		[]float64{0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05}, "event")
+	connectedClients = metrics.NewGauge("ehhworld_hub_clients",
+		"Clients connected with a player.")
+	connectedSpectators = metrics.NewGauge("ehhworld_hub_spectators",
+		"Clients connected as spectators.")
+	linkDeadPlayers = metrics.NewGauge("ehhworld_hub_link_dead_players",
+		"Players lingering in the game after losing their connection.")
+
+	outboundQueueDepth = metrics.NewHistogram("ehhworld_client_outbound_queue_depth",
+		"Messages left waiting in a client's outbound queue after each write to it, across all clients.",
+		[]float64{0, 1, 2, 4, 8, 16, 32, 64, 128, 256})
+	deepestOutboundQueue = metrics.NewGauge("ehhworld_client_outbound_queue_depth_max",
+		"Messages waiting in the deepest client outbound queue, sampled every second.")
+	backloggedClients = metrics.NewGauge("ehhworld_client_outbound_queue_backlogged",
+		"Clients whose outbound queue is more than half full, sampled every second.")
+	writeBatchSize = metrics.NewHistogram("ehhworld_client_write_batch_messages",
+		"Messages written to a client together.",
+		[]float64{1, 2, 4, 8, 16, 32, 64, 128, 256})
+	writeErrors = metrics.NewCounter("ehhworld_client_write_errors_total",
+		"Writes and pings to clients that failed, dropping the connection.")
+)
+
+// sampleOutboundQueues records how far behind the slowest clients are, without a series per client
+func (h *Hub) sampleOutboundQueues() {
+	deepest, backlogged := 0, 0
+	sample := func(c *Client) {
+		depth := len(c.outbound)
+		if depth > deepest {
+			deepest = depth
+		}
+		if depth > outboundMessageBuffer/2 {
+			backlogged++
+		}
+	}
+	for client := range h.clients {
+		sample(client)
+	}
+	for client := range h.spectators {
+		sample(client)
+	}
+
+	deepestOutboundQueue.Set(float64(deepest))
+	backloggedClients.Set(float64(backlogged))
+}
+
+// timeEvent handles a hub event, recording the time it took
+func timeEvent(event string, handle func()) {
+	start := time.Now()
+	handle()
+	hubEvents.With(event).Observe(time.Since(start).Seconds())
+}
@@ -6,13 +6,20 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"bitbucket.org/ehhio/ehhworldserver/server/account"
 	"bitbucket.org/ehhio/ehhworldserver/server/game"
-
-	"github.com/gorilla/websocket"
+	"bitbucket.org/ehhio/ehhworldserver/server/metrics"
+	"bitbucket.org/ehhio/ehhworldserver/server/store"
 )
 
 // Constants
 const (
+	// ProtocolVersion is the newest protocol version the server speaks
+	ProtocolVersion = 1
+
+	// MinProtocolVersion is the oldest protocol version the server still speaks
+	MinProtocolVersion = 1
+
 	// Time allowed to write a message to the peer
 	writeWait = 10 * time.Second
 
@@ -25,17 +32,71 @@ const (
 	// Maximum message size allowed from peer, in bytes
 	maxMessageSize = 512
 
+	// Number of undecodable messages a peer may send before being disconnected
+	maxMalformedMessages = 5
+
 	// Size of a packed-message header, in bytes
 	packedMessageHeaderSize = 2
 
 	// Outbound message channel buffer size
 	outboundMessageBuffer = 256
+
+	// Hub outbound message channel buffer size, so the game loop rarely waits on a busy hub
+	hubOutboundBuffer = 4096
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin:     CheckOrigin,
+// Config holds the settings used to serve clients
+type Config struct {
+	// The webserver address to listen on
+	Address string
+
+	// The address to accept plain TCP connections on, for clients that don't need websockets. Empty disables TCP.
+	TCPAddress string
+
+	// Web page origins allowed to open websocket connections
+	Origins *OriginPolicy
+
+	// Certificate and private key files to serve TLS with. Plain HTTP is served when empty.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// Host names to get TLS certificates for automatically, using ACME (Let's Encrypt).
+	// Certificates are cached in AutocertCacheDir, and the CA may contact AutocertEmail about problems.
+	// Takes precedence over TLSCertFile and TLSKeyFile.
+	AutocertHosts    []string
+	AutocertCacheDir string
+	AutocertEmail    string
+
+	// Size of the area around a player that is replicated to their client, in cells
+	ViewWidth  float64
+	ViewHeight float64
+
+	// Accounts clients must log in to before connecting
+	Accounts *account.Service
+
+	// Persistent game data, such as player characters
+	Store store.Store
+
+	// How often connected players are saved, besides when they disconnect. Zero only saves on disconnect.
+	SaveInterval time.Duration
+
+	// How long the player of a client that lost its connection lingers in the game, waiting for it to reconnect.
+	// Zero removes players as soon as their connection is lost.
+	ReconnectGrace time.Duration
+
+	// Budgets of messages each client may send, by message payload type (e.g. "Move"), or by chat channel
+	// (e.g. "ChatSend.Global"). Those without a budget use the default budget. A zero rate is unlimited.
+	RateLimits       map[string]RateLimit
+	DefaultRateLimit RateLimit
+
+	// Budget of login attempts from each remote address. A zero rate is unlimited.
+	LoginRateLimit RateLimit
+
+	// Cleans up chat before it is delivered. Nil delivers chat as sent.
+	ChatFilter ChatFilter
+
+	// Names of the accounts that may connect as admins and run admin commands
+	Admins []string
 }
 
 // // Network is a top-level networking object containing a websocket communication hub
@@ -52,34 +113,32 @@ var upgrader = websocket.Upgrader{
 // 	}
 // }
 
-// CheckOrigin validates incoming websocket connection origins
-func CheckOrigin(r *http.Request) bool {
-	return r.Header.Get("Origin") == "http://localhost:8080"
-}
-
-// Serve starts running a hub to handle clients via websocket connections
-func Serve(address string, game *game.Game) *Hub {
+// Serve starts running a hub to handle clients via websocket connections, and plain TCP connections when configured
+func Serve(config *Config, game *game.Game) *Hub {
 	// Start a hub
-	hub := NewHub(game)
+	hub := NewHub(config, game)
+	hub.transports = append(hub.transports, newWebsocketTransport(config))
+	if config.TCPAddress != "" {
+		hub.transports = append(hub.transports, newTCPTransport(config.TCPAddress))
+	}
 	go hub.Start()
 
-	// Configure the webserver to point to the hub
+	// Configure the webserver
 	http.HandleFunc("/", serveRoot)
-	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		handleWebsocketRequest(hub, w, r)
+	http.HandleFunc("/api/register", func(w http.ResponseWriter, r *http.Request) {
+		handleRegister(config.Accounts, w, r)
+	})
+	logins := newLoginLimiter(config.LoginRateLimit)
+	http.HandleFunc("/api/login", func(w http.ResponseWriter, r *http.Request) {
+		handleLogin(config.Accounts, logins, w, r)
 	})
+	http.HandleFunc("/api/status", hub.handleStatus)
+	http.HandleFunc("/api/map", hub.handleMap)
+	http.HandleFunc("/api/players", hub.handlePlayers)
+	http.Handle("/metrics", metrics.Handler())
 
 	// Listen
-	go func() {
-		log.WithFields(log.Fields{
-			"address": address,
-		}).Info("Starting webserver")
-
-		err := http.ListenAndServe(address, nil)
-		if err != nil {
-			log.Fatal("ListenAndServe: ", err)
-		}
-	}()
+	hub.listenAll()
 
 	return hub
 }
@@ -99,28 +158,3 @@ func serveRoot(w http.ResponseWriter, r *http.Request) {
 	}
 	http.ServeFile(w, r, "./network/websocket_test.html")
 }
-
-// handleWebsocketRequest negotiates initial websocket requests from peers
-func handleWebsocketRequest(hub *Hub, w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		log.Println(err)
-		return
-	}
-
-	client := NewClient(hub, conn)
-
-	log.WithFields(log.Fields{
-		"client": client.conn.RemoteAddr().String(),
-	}).Info("Pre Client Creation Emit")
-
-	client.hub.register <- client
-
-	log.WithFields(log.Fields{
-		"client": client.conn.RemoteAddr().String(),
-	}).Info("Starting Handlers!")
-
-	// Start client message handler routines
-	go client.outboundHandler()
-	go client.inboundHandler()
-}
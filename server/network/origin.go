@@ -0,0 +1,97 @@
+package network
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// originPattern is an allowed origin. An empty scheme matches http and https, and a host starting with "*." matches any subdomain.
+type originPattern struct {
+	scheme string
+	host   string
+}
+
+// OriginPolicy decides which web page origins may open websocket connections
+type OriginPolicy struct {
+	patterns []*originPattern
+}
+
+// NewOriginPolicy creates a policy allowing a list of origins, such as "https://ehh.io" or "https://*.ehh.io".
+// Origins without a scheme, such as "ehh.io", allow both http and https. A port must match exactly.
+func NewOriginPolicy(allowed []string) (*OriginPolicy, error) {
+	policy := &OriginPolicy{patterns: make([]*originPattern, 0, len(allowed))}
+	for _, origin := range allowed {
+		origin = strings.TrimSpace(origin)
+		if origin == "" {
+			continue
+		}
+
+		pattern := &originPattern{host: origin}
+		if strings.Contains(origin, "://") {
+			u, err := url.Parse(origin)
+			if err != nil {
+				return nil, fmt.Errorf("invalid origin %q: %v", origin, err)
+			}
+			if u.Path != "" && u.Path != "/" {
+				return nil, fmt.Errorf("invalid origin %q: origins have no path", origin)
+			}
+			pattern.scheme = strings.ToLower(u.Scheme)
+			pattern.host = u.Host
+		}
+		pattern.host = strings.ToLower(pattern.host)
+
+		if pattern.host == "" || strings.Contains(strings.TrimPrefix(pattern.host, "*."), "*") {
+			return nil, fmt.Errorf("invalid origin %q: wildcards are only allowed as the first subdomain", origin)
+		}
+
+		policy.patterns = append(policy.patterns, pattern)
+	}
+
+	return policy, nil
+}
+
+// Allows returns true if an origin matches any of the allowed origins. A nil policy allows no origins.
+func (p *OriginPolicy) Allows(origin string) bool {
+	if p == nil {
+		return false
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Host)
+
+	for _, pattern := range p.patterns {
+		if pattern.scheme == "" && scheme != "http" && scheme != "https" {
+			continue
+		}
+		if pattern.scheme != "" && pattern.scheme != scheme {
+			continue
+		}
+
+		if strings.HasPrefix(pattern.host, "*.") {
+			if strings.HasSuffix(host, pattern.host[1:]) && len(host) > len(pattern.host)-1 {
+				return true
+			}
+		} else if host == pattern.host {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckOrigin validates the origin of a websocket request, for use as websocket.Upgrader.CheckOrigin.
+// Requests without an Origin header don't come from a browser, so can't be forged by a web page, and are allowed.
+func (p *OriginPolicy) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	return p.Allows(origin)
+}
@@ -0,0 +1,203 @@
+package network
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/gamemap"
+	"bitbucket.org/ehhio/ehhworldserver/server/player"
+	"bitbucket.org/ehhio/ehhworldserver/server/store"
+	"bitbucket.org/ehhio/ehhworldserver/server/utility"
+)
+
+// characterState is the persistent state of a player's character, captured at a point in time
+type characterState struct {
+	character *store.Character
+	position  *utility.PositionHighResolution
+	inventory []*store.InventorySlot
+	known     *utility.BooleanMatrix
+}
+
+// captureState copies the persistent state of a character's player.
+// Must be called from the game loop, as the player is changed by it.
+func captureState(character *store.Character, p *player.Player) *characterState {
+	captured := *character
+	captured.Health = p.GetHealth()
+
+	position := p.GetPosition()
+	inventory := make([]*store.InventorySlot, 0, len(p.GetInventory()))
+	for _, stack := range p.GetInventory() {
+		inventory = append(inventory, &store.InventorySlot{ObjectID: stack.ObjectID, Count: stack.Count})
+	}
+
+	return &characterState{
+		character: &captured,
+		position:  &utility.PositionHighResolution{X: position.X, Y: position.Y},
+		inventory: inventory,
+		known:     p.GetMinimap().GetKnown().Copy(),
+	}
+}
+
+// newPlayer creates a player restored from the character state.
+// Characters without a saved position, or one outside of the map, spawn at a random position.
+func (s *characterState) newPlayer(id uint32, gameMap *gamemap.GameMap) *player.Player {
+	mapSize := gameMap.GetSize()
+	position := s.position
+	if position == nil || !position.IsWithinBounds(&utility.SizeHighResolution{Width: float64(mapSize.Width), Height: float64(mapSize.Height)}) {
+		position = gameMap.RandomPositionHighResolution()
+	} else {
+		position = &utility.PositionHighResolution{X: position.X, Y: position.Y}
+	}
+
+	p := player.NewPlayer(id, s.character.Name, position, gameMap.GetBlocksSize())
+	p.SetHealth(s.character.Health)
+
+	inventory := make([]*player.ItemStack, 0, len(s.inventory))
+	for _, slot := range s.inventory {
+		inventory = append(inventory, &player.ItemStack{ObjectID: slot.ObjectID, Count: slot.Count})
+	}
+	p.SetInventory(inventory)
+
+	if s.known != nil && !p.GetMinimap().SetKnown(s.known.Copy()) {
+		log.WithFields(log.Fields{
+			"character": s.character.Name,
+		}).Warn("Saved minimap does not fit the game map; Forgetting it")
+	}
+
+	return p
+}
+
+// persistence saves and loads player characters, writing saves in the background so the game loop never waits on the store.
+// Saved states are kept until written, so a player reconnecting before then restores their latest state.
+type persistence struct {
+	store store.Store
+
+	// Captured states not yet written to the store, by character ID
+	mutex   sync.Mutex
+	unsaved map[int]*characterState
+
+	// Signals the writer there are states to write, and keeps only one writer running at a time
+	wake    chan struct{}
+	writing sync.Mutex
+}
+
+// newPersistence creates a character persistence manager using a store
+func newPersistence(s store.Store) *persistence {
+	return &persistence{
+		store:   s,
+		unsaved: make(map[int]*characterState),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// start runs the background writer, until the process exits
+func (p *persistence) start() {
+	for range p.wake {
+		p.flush()
+	}
+}
+
+// load returns the latest state of the character named after an account, creating the character if it doesn't exist yet
+func (p *persistence) load(name string) (*characterState, error) {
+	character, err := p.store.GetCharacterByName(name)
+	if err == store.ErrNotFound {
+		account, err := p.store.GetAccountByName(name)
+		if err != nil {
+			return nil, err
+		}
+
+		character, err = p.store.CreateCharacter(account.ID, name, player.MaxHealth)
+		if err != nil {
+			return nil, err
+		}
+
+		log.WithFields(log.Fields{
+			"character": name,
+		}).Info("Created character")
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Prefer a state that is still waiting to be written
+	p.mutex.Lock()
+	state, exists := p.unsaved[character.ID]
+	p.mutex.Unlock()
+	if exists {
+		return state, nil
+	}
+
+	state = &characterState{character: character}
+	if state.position, err = p.store.LoadPosition(character.ID); err != nil && err != store.ErrNotFound {
+		return nil, err
+	}
+	if state.inventory, err = p.store.LoadInventory(character.ID); err != nil {
+		return nil, err
+	}
+	if state.known, err = p.store.LoadKnownBlocks(character.ID); err != nil && err != store.ErrNotFound {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// save queues a captured character state to be written to the store, replacing any older state not yet written
+func (p *persistence) save(state *characterState) {
+	p.mutex.Lock()
+	p.unsaved[state.character.ID] = state
+	p.mutex.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// flush writes every queued character state to the store, waiting until done.
+// States that fail to write stay queued, to be retried by the next flush.
+func (p *persistence) flush() {
+	p.writing.Lock()
+	defer p.writing.Unlock()
+
+	p.mutex.Lock()
+	states := make([]*characterState, 0, len(p.unsaved))
+	for _, state := range p.unsaved {
+		states = append(states, state)
+	}
+	p.mutex.Unlock()
+
+	for _, state := range states {
+		if err := p.write(state); err != nil {
+			log.WithFields(log.Fields{
+				"character": state.character.Name,
+				"error":     err,
+			}).Error("Failed to save character")
+
+			continue
+		}
+
+		// Forget the state, unless a newer one was queued while writing
+		p.mutex.Lock()
+		if p.unsaved[state.character.ID] == state {
+			delete(p.unsaved, state.character.ID)
+		}
+		p.mutex.Unlock()
+	}
+}
+
+// write stores every part of a character state
+func (p *persistence) write(state *characterState) error {
+	id := state.character.ID
+
+	if err := p.store.UpdateCharacter(state.character); err != nil {
+		return err
+	}
+	if err := p.store.SavePosition(id, state.position); err != nil {
+		return err
+	}
+	if err := p.store.SaveInventory(id, state.inventory); err != nil {
+		return err
+	}
+
+	return p.store.SaveKnownBlocks(id, state.known)
+}
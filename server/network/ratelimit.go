@@ -0,0 +1,215 @@
+package network
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/protobuf"
+)
+
+// Constants
+const (
+	// Violations within this long of the last strike don't add another strike
+	strikeWindow = time.Second
+
+	// Strikes are forgiven after this long without violations
+	strikeDecay = 30 * time.Second
+
+	// Number of strikes at which clients are throttled, and then kicked. Fewer strikes only warn.
+	throttleStrikes = 3
+	kickStrikes     = 5
+
+	// How long a throttled client's messages are dropped before reaching the hub
+	throttleDuration = 2 * time.Second
+)
+
+// RateLimit is a budget of messages a client may send: a steady rate per second, and a burst allowed above it
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+func (r RateLimit) String() string {
+	return fmt.Sprintf("%v/%v", r.Rate, r.Burst)
+}
+
+// ParseRateLimit parses a rate limit written as "rate/burst", such as "20/40". A zero rate, such as "0/1", is unlimited.
+func ParseRateLimit(value string) (RateLimit, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 {
+		return RateLimit{}, fmt.Errorf("rate limit %q is not written as rate/burst", value)
+	}
+
+	rate, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || rate < 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return RateLimit{}, fmt.Errorf("rate limit %q has an invalid rate", value)
+	}
+	burst, err := strconv.Atoi(parts[1])
+	if err != nil || burst < 1 {
+		return RateLimit{}, fmt.Errorf("rate limit %q has an invalid burst", value)
+	}
+
+	return RateLimit{Rate: rate, Burst: burst}, nil
+}
+
+// ParseRateLimits parses rate limits by message payload type, written as comma separated "type=rate/burst",
+// such as "Move=30/60,Hello=1/3"
+func ParseRateLimits(value string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("rate limit %q is not written as type=rate/burst", entry)
+		}
+
+		limit, err := ParseRateLimit(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		limits[strings.TrimSpace(parts[0])] = limit
+	}
+
+	return limits, nil
+}
+
+// tokenBucket refills with tokens at a steady rate up to a capacity, and allows an action for each token taken
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates a full token bucket
+func newTokenBucket(limit RateLimit, now time.Time) *tokenBucket {
+	return &tokenBucket{limit: limit, tokens: float64(limit.Burst), last: now}
+}
+
+// take refills the bucket for the time passed, then takes a token.
+// Returns false if the bucket is empty.
+func (b *tokenBucket) take(now time.Time) bool {
+	b.tokens += now.Sub(b.last).Seconds() * b.limit.Rate
+	if b.tokens > float64(b.limit.Burst) {
+		b.tokens = float64(b.limit.Burst)
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// allow takes a token from the client's bucket for a message payload type, creating the bucket on first use
+func (c *Client) allow(payload string, now time.Time) bool {
+	bucket, exists := c.buckets[payload]
+	if !exists {
+		limit, exists := c.hub.config.RateLimits[payload]
+		if !exists {
+			limit = c.hub.config.DefaultRateLimit
+		}
+		if limit.Rate <= 0 {
+			return true
+		}
+
+		bucket = newTokenBucket(limit, now)
+		c.buckets[payload] = bucket
+	}
+
+	return bucket.take(now)
+}
+
+// isThrottled returns true while the client's messages are being dropped for flooding.
+// Safe to call from the client's inbound goroutine.
+func (c *Client) isThrottled(now time.Time) bool {
+	return now.UnixNano() < atomic.LoadInt64(&c.throttledUntil)
+}
+
+// strike records a rate limit violation by a client, and warns, throttles or kicks it depending on its strikes.
+// At most one strike is given per strike window.
+func (h *Hub) strike(ctx *Context, payload string, now time.Time) error {
+	c := ctx.Client
+	if now.Sub(c.lastStrike) > strikeDecay {
+		c.strikes = 0
+	}
+
+	// Drop further violations within the strike window quietly, rather than replying to every one
+	if c.strikes > 0 && now.Sub(c.lastStrike) < strikeWindow {
+		return nil
+	}
+	c.strikes++
+	c.lastStrike = now
+
+	fields := log.Fields{
+		"client address": c.conn.RemoteAddr().String(),
+		"name":           c.name,
+		"payload":        payload,
+		"strikes":        c.strikes,
+	}
+
+	switch {
+	case c.strikes >= kickStrikes:
+		log.WithFields(fields).Warn("Client Disconnected; Rate limit exceeded")
+
+		h.sendError(c, protobuf.Error_RATE_LIMITED, "too many messages; disconnecting")
+		disconnects.With(disconnectRateLimited).Inc()
+		h.removeClient(c, ctx.Player)
+		return nil
+	case c.strikes >= throttleStrikes:
+		log.WithFields(fields).Warn("Client throttled; Rate limit exceeded")
+
+		atomic.StoreInt64(&c.throttledUntil, now.Add(throttleDuration).UnixNano())
+		return &HandlerError{
+			Code:   protobuf.Error_RATE_LIMITED,
+			Reason: fmt.Sprintf("too many messages; ignoring all messages for %v", throttleDuration),
+		}
+	default:
+		log.WithFields(fields).Info("Client warned; Rate limit exceeded")
+
+		return &HandlerError{
+			Code:   protobuf.Error_RATE_LIMITED,
+			Reason: fmt.Sprintf("too many %v messages; slow down", payload),
+		}
+	}
+}
+
+// rateLimitKey returns the name of the budget a message counts against: its payload type, along with the
+// channel for chat, so each chat channel has its own budget (e.g. "ChatSend.Global").
+// Unknown chat channels all share the "ChatSend.Unknown" budget, so made up channels can't each get a fresh one.
+func rateLimitKey(msg *protobuf.Message) string {
+	if chat := msg.GetChatSend(); chat != nil {
+		name, known := protobuf.ChatChannel_name[int32(chat.Channel)]
+		if !known {
+			name = "Unknown"
+		}
+		return "ChatSend." + strings.Title(strings.ToLower(name))
+	}
+
+	return payloadName(msg)
+}
+
+// RateLimitMiddleware drops messages over the client's budget for their payload type or chat channel,
+// and enforces the strike policy
+func RateLimitMiddleware(next Handler) Handler {
+	return func(ctx *Context) error {
+		now := time.Now()
+		payload := rateLimitKey(ctx.Message)
+		if !ctx.Client.allow(payload, now) {
+			return ctx.Hub.strike(ctx, payload, now)
+		}
+
+		return next(ctx)
+	}
+}
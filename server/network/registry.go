@@ -0,0 +1,126 @@
+package network
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/player"
+	"bitbucket.org/ehhio/ehhworldserver/server/protobuf"
+)
+
+// Context describes a decoded client message being handled
+type Context struct {
+	// The hub that received the message
+	Hub *Hub
+
+	// The client that sent the message, and its player
+	Client *Client
+	Player *player.Player
+
+	// The decoded message
+	Message *protobuf.Message
+}
+
+// Handler handles one type of message payload from clients.
+// Handlers run on the hub goroutine, so any game state change must be queued with Game.Enqueue.
+// Returning a HandlerError replies to the client with a protocol error.
+type Handler func(ctx *Context) error
+
+// Middleware wraps a handler, to run code before and after it or to stop it from running at all
+type Middleware func(next Handler) Handler
+
+// HandlerError is a problem handling a message that should be reported back to the client
+type HandlerError struct {
+	Code   protobuf.Error_Code
+	Reason string
+}
+
+func (e *HandlerError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Code, e.Reason)
+}
+
+// Registry routes client messages to the handler registered for their payload type
+type Registry struct {
+	handlers   map[reflect.Type]Handler
+	middleware []Middleware
+}
+
+// NewRegistry creates an empty message handler registry
+func NewRegistry() *Registry {
+	return &Registry{
+		handlers:   make(map[reflect.Type]Handler),
+		middleware: make([]Middleware, 0),
+	}
+}
+
+// Handle registers the handler for a payload type, replacing any existing handler.
+// payload is a value of the payload wrapper type, such as (*protobuf.Message_Move)(nil).
+func (r *Registry) Handle(payload interface{}, handler Handler) {
+	r.handlers[reflect.TypeOf(payload)] = handler
+}
+
+// Use adds middleware around every handler. Middleware added first runs first.
+func (r *Registry) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Dispatch runs the handler registered for a message's payload type, wrapped in all middleware
+func (r *Registry) Dispatch(ctx *Context) error {
+	handler, exists := r.handlers[reflect.TypeOf(ctx.Message.Payload)]
+	if !exists {
+		return &HandlerError{
+			Code:   protobuf.Error_UNSUPPORTED,
+			Reason: fmt.Sprintf("unsupported message payload %T", ctx.Message.Payload),
+		}
+	}
+
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = r.middleware[i](handler)
+	}
+
+	return handler(ctx)
+}
+
+// payloadName returns a short name for the payload type of a message, for logs and metrics
+func payloadName(msg *protobuf.Message) string {
+	if msg.Payload == nil {
+		return "none"
+	}
+
+	return strings.TrimPrefix(reflect.TypeOf(msg.Payload).Elem().Name(), "Message_")
+}
+
+// LoggingMiddleware logs every handled message and how long it took to handle
+func LoggingMiddleware(next Handler) Handler {
+	return func(ctx *Context) error {
+		start := time.Now()
+		err := next(ctx)
+
+		log.WithFields(log.Fields{
+			"client address": ctx.Client.conn.RemoteAddr().String(),
+			"payload":        payloadName(ctx.Message),
+			"duration":       time.Now().Sub(start),
+			"error":          err,
+		}).Debug("Handled message")
+
+		return err
+	}
+}
+
+// HandshakeMiddleware refuses every message but a Hello until the client has been welcomed
+func HandshakeMiddleware(next Handler) Handler {
+	return func(ctx *Context) error {
+		if _, isHello := ctx.Message.Payload.(*protobuf.Message_Hello); !isHello && !ctx.Client.welcomed {
+			return &HandlerError{
+				Code:   protobuf.Error_HANDSHAKE_REQUIRED,
+				Reason: "send a hello before any other message",
+			}
+		}
+
+		return next(ctx)
+	}
+}
@@ -0,0 +1,198 @@
+package network
+
+import (
+	"bitbucket.org/ehhio/ehhworldserver/server/game"
+	"bitbucket.org/ehhio/ehhworldserver/server/npc"
+	"bitbucket.org/ehhio/ehhworldserver/server/player"
+	"bitbucket.org/ehhio/ehhworldserver/server/protobuf"
+	"bitbucket.org/ehhio/ehhworldserver/server/utility"
+)
+
+// Replicable is the interface game objects must satisfy to be sent to clients in snapshots
+type Replicable interface {
+	GetID() uint32
+	GetName() string
+	GetPosition() *utility.PositionHighResolution
+	GetOrientation() float64
+	GetHealth() int32
+	IsLinkDead() bool
+}
+
+// Verify that *Player implements Replicable
+var _ Replicable = (*player.Player)(nil)
+
+// Verify that *NPC implements Replicable
+var _ Replicable = (*npc.NPC)(nil)
+
+// Verify that *Client implements IViewer
+var _ game.IViewer = (*Client)(nil)
+
+// View sends the client a snapshot of the game state in view of its player or camera, implementing game.IViewer.
+// When the client has acknowledged a snapshot we still remember, only the changes since that snapshot are sent.
+func (c *Client) View(tick uint32, g *game.Game) {
+	center := c.viewCenter(g)
+	if center == nil {
+		return
+	}
+
+	// Map blocks first, so entities have ground to stand on
+	c.streamBlocks(g, center)
+
+	states := collectEntityStates(c.viewRect(center), c.viewSize, g)
+
+	var baseline *snapshotRecord
+	if c.acked {
+		baseline = c.history.get(c.ackedTick)
+	}
+
+	snapshot := newSnapshot(tick, states, baseline)
+	snapshot.Entered, snapshot.Left = c.updateVisible(states)
+	snapshot.AckSequence = c.inputSequence
+	c.history.add(tick, states)
+	c.send(&protobuf.Message{Payload: &protobuf.Message_Snapshot{Snapshot: snapshot}})
+}
+
+// processedInput records that the game has processed a client input, to be echoed back in snapshots.
+// The client uses this to replay its unacknowledged inputs on top of the authoritative state.
+func (c *Client) processedInput(sequence uint32) {
+	if sequence > c.inputSequence {
+		c.inputSequence = sequence
+	}
+}
+
+// viewRect returns the bottom left point of the area of the world in view of the client, around its view center
+func (c *Client) viewRect(center *utility.PositionHighResolution) *utility.PositionHighResolution {
+	return &utility.PositionHighResolution{X: center.X - c.viewSize.Width/2.0, Y: center.Y - c.viewSize.Height/2.0}
+}
+
+// updateVisible replaces the set of entities in view of the client.
+// Returns the IDs of entities that entered and left the view since the last update.
+func (c *Client) updateVisible(states map[uint32]*protobuf.EntityState) (entered, left []uint32) {
+	for id := range states {
+		if !c.visible[id] {
+			entered = append(entered, id)
+		}
+	}
+	for id := range c.visible {
+		if _, exists := states[id]; !exists {
+			left = append(left, id)
+		}
+	}
+
+	c.visible = make(map[uint32]bool, len(states))
+	for id := range states {
+		c.visible[id] = true
+	}
+
+	return
+}
+
+// acknowledge records the latest snapshot the client has received, to encode future snapshots against.
+// Acks arriving late or out of order, and acks of snapshots no longer remembered, are ignored.
+func (c *Client) acknowledge(tick uint32) {
+	if c.acked && tick <= c.ackedTick {
+		return
+	}
+	if c.history.get(tick) == nil {
+		return
+	}
+
+	c.ackedTick = tick
+	c.acked = true
+}
+
+// collectEntityStates captures the full state of all replicable game objects within a rect, by entity ID
+func collectEntityStates(bottomLeft *utility.PositionHighResolution, size *utility.SizeHighResolution, g *game.Game) map[uint32]*protobuf.EntityState {
+	states := make(map[uint32]*protobuf.EntityState)
+	for _, object := range g.GetCollision().GetAllWithinRect(bottomLeft, size) {
+		if entity, ok := object.(Replicable); ok {
+			states[entity.GetID()] = newEntityState(entity)
+		}
+	}
+
+	return states
+}
+
+// newSnapshot builds a snapshot from full entity states. If a baseline is given, the snapshot is a delta against it.
+func newSnapshot(tick uint32, states map[uint32]*protobuf.EntityState, baseline *snapshotRecord) *protobuf.Snapshot {
+	snapshot := &protobuf.Snapshot{Tick: tick}
+
+	// Full snapshot
+	if baseline == nil {
+		for _, state := range states {
+			snapshot.Entities = append(snapshot.Entities, state)
+		}
+		return snapshot
+	}
+
+	// Delta snapshot
+	snapshot.Delta = true
+	snapshot.BaseTick = baseline.tick
+	for id, state := range states {
+		base, exists := baseline.states[id]
+		if !exists {
+			snapshot.Entities = append(snapshot.Entities, state)
+			continue
+		}
+
+		if delta := diffEntityState(base, state); delta != nil {
+			snapshot.Entities = append(snapshot.Entities, delta)
+		}
+	}
+	for id := range baseline.states {
+		if _, exists := states[id]; !exists {
+			snapshot.Removed = append(snapshot.Removed, id)
+		}
+	}
+
+	return snapshot
+}
+
+// newEntityState captures the full replicated state of a game object
+func newEntityState(entity Replicable) *protobuf.EntityState {
+	position := entity.GetPosition()
+	changed := player.NewDirtyFlagsBitSet(player.FlagDirtyPosition, player.FlagDirtyOrientation, player.FlagDirtyHealth, player.FlagDirtyLinkDead)
+
+	return &protobuf.EntityState{
+		Id:          entity.GetID(),
+		Name:        entity.GetName(),
+		X:           position.X,
+		Y:           position.Y,
+		Orientation: entity.GetOrientation(),
+		Health:      entity.GetHealth(),
+		LinkDead:    entity.IsLinkDead(),
+		Changed:     uint32(changed.Flags),
+	}
+}
+
+// diffEntityState encodes only the fields of an entity state that differ from a base state.
+// Returns nil if nothing changed.
+func diffEntityState(base, state *protobuf.EntityState) *protobuf.EntityState {
+	changed := player.NewDirtyFlagsBitSet()
+	delta := &protobuf.EntityState{Id: state.Id}
+
+	if base.X != state.X || base.Y != state.Y {
+		changed.Flags.Set(player.FlagDirtyPosition)
+		delta.X = state.X
+		delta.Y = state.Y
+	}
+	if base.Orientation != state.Orientation {
+		changed.Flags.Set(player.FlagDirtyOrientation)
+		delta.Orientation = state.Orientation
+	}
+	if base.Health != state.Health {
+		changed.Flags.Set(player.FlagDirtyHealth)
+		delta.Health = state.Health
+	}
+	if base.LinkDead != state.LinkDead {
+		changed.Flags.Set(player.FlagDirtyLinkDead)
+		delta.LinkDead = state.LinkDead
+	}
+
+	if changed.Flags == 0 {
+		return nil
+	}
+
+	delta.Changed = uint32(changed.Flags)
+	return delta
+}
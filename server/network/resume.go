@@ -0,0 +1,105 @@
+package network
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/game"
+	"bitbucket.org/ehhio/ehhworldserver/server/player"
+)
+
+// Constants
+const (
+	// Longest a resume token may be used for. Tokens are also ended as soon as their player leaves the game.
+	resumeTokenLifetime = 24 * time.Hour
+
+	// How often link-dead players are checked for an expired grace period
+	linkDeadSweepPeriod = time.Second
+)
+
+// linkDeadPlayer is a player whose client lost its connection, lingering in the game for the client to reconnect
+type linkDeadPlayer struct {
+	client  *Client
+	player  *player.Player
+	expires time.Time
+}
+
+// linger keeps the player of a client that lost its connection in the game, marked as link-dead, for the reconnect grace period.
+// Clients that were never welcomed, and so never got a resume token, are removed right away.
+func (h *Hub) linger(client *Client, p *player.Player) {
+	if h.config.ReconnectGrace <= 0 || !client.welcomed {
+		h.removeClient(client, p)
+		return
+	}
+
+	delete(h.clients, client)
+	close(client.outbound)
+	h.linkDead[client.name] = &linkDeadPlayer{
+		client:  client,
+		player:  p,
+		expires: time.Now().Add(h.config.ReconnectGrace),
+	}
+
+	character := client.character.character
+	h.game.Enqueue(func(g *game.Game) {
+		g.RemoveViewer(client)
+		p.SetLinkDead(true)
+		h.persistence.save(captureState(character, p))
+	})
+}
+
+// reattach hands a link-dead player over to a new client for the same account.
+// Returns false if the account has no link-dead player.
+func (h *Hub) reattach(client *Client) bool {
+	lingering, exists := h.linkDead[client.name]
+	if !exists {
+		return false
+	}
+
+	delete(h.linkDead, client.name)
+	h.resumes.End(lingering.client.resumeToken)
+
+	h.clients[client] = lingering.player
+	client.player = lingering.player
+	client.character = lingering.client.character
+	client.resumed = true
+
+	// Carry on acknowledging inputs from where the old connection left off
+	from, p := lingering.client, lingering.player
+	h.game.Enqueue(func(g *game.Game) {
+		client.inputSequence = from.inputSequence
+		p.SetLinkDead(false)
+		p.GetMinimap().ResetExplored()
+	})
+
+	return true
+}
+
+// expireLinkDead removes link-dead players whose grace period has passed
+func (h *Hub) expireLinkDead() {
+	now := time.Now()
+	for name, lingering := range h.linkDead {
+		if now.Before(lingering.expires) {
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"name": name,
+		}).Info("Player removed; Reconnect grace period expired")
+
+		h.removeLinkDead(name, lingering)
+	}
+}
+
+// removeLinkDead removes a link-dead player from the game, saving it
+func (h *Hub) removeLinkDead(name string, lingering *linkDeadPlayer) {
+	delete(h.linkDead, name)
+	h.resumes.End(lingering.client.resumeToken)
+
+	character, p := lingering.client.character.character, lingering.player
+	h.game.Enqueue(func(g *game.Game) {
+		g.RemoveObject(p)
+		h.persistence.save(captureState(character, p))
+	})
+}
@@ -0,0 +1,161 @@
+package network
+
+import (
+	"fmt"
+	"math"
+
+	log "github.com/sirupsen/logrus"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/game"
+	"bitbucket.org/ehhio/ehhworldserver/server/player"
+	"bitbucket.org/ehhio/ehhworldserver/server/player/minimap"
+	"bitbucket.org/ehhio/ehhworldserver/server/protobuf"
+	"bitbucket.org/ehhio/ehhworldserver/server/utility"
+)
+
+// spectatorPayloads are the only message payloads spectators may send
+var spectatorPayloads = map[string]bool{
+	"Hello":        true,
+	"SnapshotAck":  true,
+	"Spectate":     true,
+	"AdminCommand": true,
+}
+
+// newSpectator constructs a client that watches the game without a player, through a camera it moves freely.
+// The camera starts at the center of the map.
+func newSpectator(hub *Hub, conn Conn, name string) *Client {
+	gameMap := hub.game.GetGameMap()
+	size := gameMap.GetSize()
+
+	c := NewClient(hub, conn, name)
+	c.spectator = true
+	c.camera = &utility.PositionHighResolution{X: float64(size.Width) / 2.0, Y: float64(size.Height) / 2.0}
+	c.explored = minimap.NewMinimap(gameMap.GetBlocksSize())
+	return c
+}
+
+// viewCenter returns the point the client's view is centered on: its player, or a spectator's camera.
+// Returns nil if the client has nothing to view yet.
+// Must only be called from the game loop.
+func (c *Client) viewCenter(g *game.Game) *utility.PositionHighResolution {
+	if c.player != nil {
+		return c.player.GetPosition()
+	}
+	if !c.spectator {
+		return nil
+	}
+
+	// Stop following players that left the game, leaving the camera where they were last seen
+	if c.following != nil {
+		if !g.HasObject(c.following) {
+			c.following = nil
+		} else {
+			position := c.following.GetPosition()
+			c.camera = &utility.PositionHighResolution{X: position.X, Y: position.Y}
+		}
+	}
+
+	return c.camera
+}
+
+// explorer returns the minimap tracking which map blocks the client has been sent
+func (c *Client) explorer() *minimap.Minimap {
+	if c.player != nil {
+		return c.player.GetMinimap()
+	}
+
+	return c.explored
+}
+
+// findPlayer returns the player in the game with a name, including link-dead players, or nil if there is none
+func (h *Hub) findPlayer(name string) *player.Player {
+	for _, p := range h.clients {
+		if p.GetName() == name {
+			return p
+		}
+	}
+	for _, lingering := range h.linkDead {
+		if lingering.player.GetName() == name {
+			return lingering.player
+		}
+	}
+
+	return nil
+}
+
+// removeSpectator drops a spectator from the hub and the game, and closes its outbound channel.
+// Does nothing if the spectator was already removed.
+func (h *Hub) removeSpectator(client *Client) {
+	if !h.spectators[client] {
+		return
+	}
+
+	delete(h.spectators, client)
+	close(client.outbound)
+	h.game.Enqueue(func(g *game.Game) {
+		g.RemoveViewer(client)
+	})
+}
+
+func handleSpectate(ctx *Context) error {
+	c := ctx.Client
+	msg := ctx.Message.GetSpectate()
+
+	if math.IsNaN(msg.X) || math.IsInf(msg.X, 0) || math.IsNaN(msg.Y) || math.IsInf(msg.Y, 0) {
+		return &HandlerError{Code: protobuf.Error_INVALID, Reason: "camera positions must be finite numbers"}
+	}
+
+	var following *player.Player
+	if msg.Follow != "" {
+		following = ctx.Hub.findPlayer(msg.Follow)
+		if following == nil {
+			return &HandlerError{
+				Code:   protobuf.Error_NOT_FOUND,
+				Reason: fmt.Sprintf("no player named %v is in the game", msg.Follow),
+			}
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"client address": c.conn.RemoteAddr().String(),
+		"x":              msg.X,
+		"y":              msg.Y,
+		"follow":         msg.Follow,
+	}).Debug("Spectator moved camera")
+
+	// Keep the camera on the map
+	size := ctx.Hub.game.GetGameMap().GetSize()
+	camera := &utility.PositionHighResolution{
+		X: utility.ClampHighResolution(msg.X, 0, math.Nextafter(float64(size.Width), 0)),
+		Y: utility.ClampHighResolution(msg.Y, 0, math.Nextafter(float64(size.Height), 0)),
+	}
+	ctx.Hub.game.Enqueue(func(g *game.Game) {
+		c.following = following
+		if following == nil {
+			c.camera = camera
+		}
+	})
+
+	return nil
+}
+
+// SpectatorMiddleware refuses gameplay messages from spectators, and camera messages from players
+func SpectatorMiddleware(next Handler) Handler {
+	return func(ctx *Context) error {
+		payload := payloadName(ctx.Message)
+		if ctx.Client.spectator && !spectatorPayloads[payload] {
+			return &HandlerError{
+				Code:   protobuf.Error_WRONG_MODE,
+				Reason: fmt.Sprintf("spectators can't send %v messages", payload),
+			}
+		}
+		if !ctx.Client.spectator && payload == "Spectate" {
+			return &HandlerError{
+				Code:   protobuf.Error_WRONG_MODE,
+				Reason: "only spectators have a camera to move",
+			}
+		}
+
+		return next(ctx)
+	}
+}
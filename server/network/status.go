@@ -0,0 +1,231 @@
+package network
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/game"
+	"bitbucket.org/ehhio/ehhworldserver/server/player"
+)
+
+// Constants
+const (
+	// Time allowed for the hub or game loop to answer a status request
+	statusQueryWait = 2 * time.Second
+)
+
+// Errors
+var (
+	// errQueryTimeout is returned when the hub or game loop doesn't answer a status request in time
+	errQueryTimeout = errors.New("server did not answer in time")
+)
+
+// statusResponse is the JSON body of status requests
+type statusResponse struct {
+	Uptime     float64 `json:"uptime"`
+	Frame      uint16  `json:"frame"`
+	TickRate   int     `json:"tickRate"`
+	SendRate   int     `json:"sendRate"`
+	Clients    int     `json:"clients"`
+	Spectators int     `json:"spectators"`
+	LinkDead   int     `json:"linkDead"`
+}
+
+// mapResponse is the JSON body of map requests
+type mapResponse struct {
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	BlockWidth  int    `json:"blockWidth"`
+	BlockHeight int    `json:"blockHeight"`
+	Seed        int64  `json:"seed"`
+	Mode        string `json:"mode"`
+}
+
+// playerResponse describes one player in the JSON body of players requests
+type playerResponse struct {
+	ID       uint32  `json:"id"`
+	Name     string  `json:"name"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Health   int32   `json:"health"`
+	LinkDead bool    `json:"linkDead"`
+}
+
+// query runs a function on the hub goroutine, so it may read hub state, and returns what it returned.
+// The result is only handed over once the function is done with it, so a late answer after a timeout is dropped.
+func (h *Hub) query(query func() interface{}) (interface{}, error) {
+	results := make(chan interface{}, 1)
+	timeout := time.NewTimer(statusQueryWait)
+	defer timeout.Stop()
+
+	select {
+	case h.queries <- func() { results <- query() }:
+	case <-h.stopped:
+		return nil, errQueryTimeout
+	case <-timeout.C:
+		return nil, errQueryTimeout
+	}
+
+	select {
+	case result := <-results:
+		return result, nil
+	case <-timeout.C:
+		return nil, errQueryTimeout
+	}
+}
+
+// queryGame runs a function on the game loop, so it may read game state, and returns what it returned.
+// The result is only handed over once the function is done with it, so a late answer after a timeout is dropped.
+func (h *Hub) queryGame(query func(g *game.Game) interface{}) (interface{}, error) {
+	results := make(chan interface{}, 1)
+	h.game.Enqueue(func(g *game.Game) {
+		results <- query(g)
+	})
+
+	select {
+	case result := <-results:
+		return result, nil
+	case <-time.After(statusQueryWait):
+		return nil, errQueryTimeout
+	}
+}
+
+// allowGet replies with an error to requests that aren't GET requests
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != "GET" {
+		http.Error(w, "Method Not Allowed", 405)
+		return false
+	}
+
+	return true
+}
+
+// allowAdmin replies with an error to requests that don't carry the session token of an admin account,
+// given as a bearer token in the Authorization header or as the "token" query parameter
+func (h *Hub) allowAdmin(w http.ResponseWriter, r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		token = strings.TrimPrefix(header, "Bearer ")
+	}
+
+	name, err := h.authenticate(token, "")
+	if err != nil {
+		http.Error(w, "Unauthorized", 401)
+		return false
+	}
+	if !h.isAdmin(name) {
+		log.WithFields(log.Fields{
+			"address": r.RemoteAddr,
+			"name":    name,
+			"path":    r.URL.Path,
+		}).Warn("API request refused; Not an admin")
+
+		http.Error(w, "Forbidden", 403)
+		return false
+	}
+
+	return true
+}
+
+// handleStatus replies with the uptime and load of the server
+func (h *Hub) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+
+	counts, err := h.query(func() interface{} {
+		return statusResponse{
+			Clients:    len(h.clients),
+			Spectators: len(h.spectators),
+			LinkDead:   len(h.linkDead),
+		}
+	})
+	var answer interface{}
+	if err == nil {
+		answer, err = h.queryGame(func(g *game.Game) interface{} {
+			status := counts.(statusResponse)
+			status.Uptime = time.Since(g.GetStartTime()).Seconds()
+			status.Frame = g.GetFrame()
+			status.TickRate = g.GetTickRate()
+			status.SendRate = g.GetSendRate()
+			return status
+		})
+	}
+	if err != nil {
+		http.Error(w, err.Error(), 503)
+		return
+	}
+
+	status := answer.(statusResponse)
+	writeJSON(w, 200, status)
+}
+
+// handleMap replies with the size of the game map and how it was generated
+func (h *Hub) handleMap(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+
+	// The map's layout is fixed once generated, so is safe to read from here
+	gameMap := h.game.GetGameMap()
+	size, blockSize := gameMap.GetSize(), gameMap.GetBlockSize()
+	writeJSON(w, 200, &mapResponse{
+		Width:       size.Width,
+		Height:      size.Height,
+		BlockWidth:  blockSize.Width,
+		BlockHeight: blockSize.Height,
+		Seed:        gameMap.GetSeed(),
+		Mode:        gameMap.GetGenerationMode().String(),
+	})
+}
+
+// handlePlayers replies with the players in the game and where they are, sorted by name.
+// Only admins may ask, as where everyone is would otherwise give players an unfair advantage.
+func (h *Hub) handlePlayers(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) || !h.allowAdmin(w, r) {
+		return
+	}
+
+	found, err := h.query(func() interface{} {
+		players := make([]*player.Player, 0, len(h.clients)+len(h.linkDead))
+		for _, p := range h.clients {
+			players = append(players, p)
+		}
+		for _, lingering := range h.linkDead {
+			players = append(players, lingering.player)
+		}
+		return players
+	})
+	var answer interface{}
+	if err == nil {
+		players := found.([]*player.Player)
+		answer, err = h.queryGame(func(g *game.Game) interface{} {
+			response := make([]*playerResponse, 0, len(players))
+			for _, p := range players {
+				position := p.GetPosition()
+				response = append(response, &playerResponse{
+					ID:       p.GetID(),
+					Name:     p.GetName(),
+					X:        position.X,
+					Y:        position.Y,
+					Health:   p.GetHealth(),
+					LinkDead: p.IsLinkDead(),
+				})
+			}
+			return response
+		})
+	}
+	if err != nil {
+		http.Error(w, err.Error(), 503)
+		return
+	}
+
+	response := answer.([]*playerResponse)
+	sort.Slice(response, func(i, j int) bool { return response[i].Name < response[j].Name })
+	writeJSON(w, 200, response)
+}
@@ -0,0 +1,236 @@
+package network
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/protobuf"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Constants
+const (
+	// Time allowed for a TCP peer to send its Hello after connecting
+	tcpHandshakeWait = 10 * time.Second
+)
+
+// tcpTransport serves clients over plain TCP connections, for native clients and bots that have no use for HTTP.
+// Messages are framed with framing v2. With no HTTP request to carry credentials, the peer's first message
+// must be a Hello holding its session token or resume token.
+type tcpTransport struct {
+	// The address to listen on
+	address string
+
+	// The listener, once listening, and whether the transport was closed
+	mutex    sync.Mutex
+	listener net.Listener
+	closed   bool
+}
+
+// newTCPTransport creates a transport accepting TCP connections on an address
+func newTCPTransport(address string) *tcpTransport {
+	return &tcpTransport{address: address}
+}
+
+// Listen accepts TCP connections until the transport is closed
+func (t *tcpTransport) Listen(hub *Hub) error {
+	listener, err := net.Listen("tcp", t.address)
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	if t.closed {
+		t.mutex.Unlock()
+		return listener.Close()
+	}
+	t.listener = listener
+	t.mutex.Unlock()
+
+	log.WithFields(log.Fields{
+		"address": t.address,
+	}).Info("Starting TCP listener")
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			t.mutex.Lock()
+			closed := t.closed
+			t.mutex.Unlock()
+			if closed {
+				return nil
+			}
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				continue
+			}
+			return err
+		}
+
+		go t.handshake(hub, newTCPConn(conn))
+	}
+}
+
+// Close stops accepting TCP connections
+func (t *tcpTransport) Close() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.closed = true
+	if t.listener == nil {
+		return nil
+	}
+	return t.listener.Close()
+}
+
+// handshake authenticates a new TCP peer by the Hello it must send first, then hands it to the hub.
+// The Hello is left to be read again, so the hub handles it like a Hello from any other client.
+func (t *tcpTransport) handshake(hub *Hub, conn *tcpConn) {
+	refuse := func(reason string, err error) {
+		log.WithFields(log.Fields{
+			"address": conn.RemoteAddr().String(),
+			"error":   err,
+		}).Info("TCP connection refused; " + reason)
+
+		conn.Close()
+	}
+
+	conn.conn.SetReadDeadline(time.Now().Add(tcpHandshakeWait))
+	data, err := conn.ReadMessage()
+	if err != nil {
+		refuse("Read error", err)
+		return
+	}
+
+	msg := &protobuf.Message{}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		refuse("Malformed hello", err)
+		return
+	}
+	hello := msg.GetHello()
+	if hello == nil {
+		refuse("Expected hello", nil)
+		return
+	}
+
+	name, err := hub.authenticate(hello.Token, hello.ResumeToken)
+	if err != nil {
+		reject, _ := proto.Marshal(&protobuf.Message{Payload: &protobuf.Message_Reject{Reject: &protobuf.Reject{
+			Reason:          "not authenticated; log in and send the session token in the hello",
+			ProtocolVersion: ProtocolVersion,
+		}}})
+		conn.WriteMessages([][]byte{reject})
+		refuse("Not authenticated", err)
+		return
+	}
+
+	conn.unread = data
+	if hello.Spectate {
+		hub.accept(newSpectator(hub, conn, name))
+		return
+	}
+
+	character, err := hub.persistence.load(name)
+	if err != nil {
+		refuse("Failed to load character", err)
+		return
+	}
+
+	client := NewClient(hub, conn, name)
+	client.character = character
+	hub.accept(client)
+}
+
+// tcpConn carries client messages over a TCP connection, each prefixed by its varint length as in framing v2.
+// An empty frame is a heartbeat; either side sends one to keep an idle connection alive.
+// Peers must send a message or heartbeat at least every pongWait, or are disconnected.
+type tcpConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	writer *bufio.Writer
+
+	// A message already read during the handshake, to be read again
+	unread []byte
+}
+
+// newTCPConn wraps an accepted TCP connection
+func newTCPConn(conn net.Conn) *tcpConn {
+	return &tcpConn{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		writer: bufio.NewWriter(conn),
+	}
+}
+
+// ReadMessage returns the next message from the peer, skipping heartbeats
+func (c *tcpConn) ReadMessage() ([]byte, error) {
+	if c.unread != nil {
+		message := c.unread
+		c.unread = nil
+		return message, nil
+	}
+
+	for {
+		length, err := binary.ReadUvarint(c.reader)
+		if err != nil {
+			return nil, err
+		}
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		if length == 0 {
+			continue
+		}
+		if length > maxMessageSizeV2 {
+			return nil, errFrameTooLarge
+		}
+
+		message := make([]byte, length)
+		if _, err := io.ReadFull(c.reader, message); err != nil {
+			return nil, err
+		}
+
+		return message, nil
+	}
+}
+
+// WriteMessages frames messages and writes them to the peer together
+func (c *tcpConn) WriteMessages(messages [][]byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	for _, message := range messages {
+		packed, err := framingV2.pack(message)
+		if err != nil {
+			return err
+		}
+		if _, err := c.writer.Write(packed); err != nil {
+			return err
+		}
+	}
+
+	return c.writer.Flush()
+}
+
+// Ping sends the peer a heartbeat
+func (c *tcpConn) Ping() error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	if err := c.writer.WriteByte(0); err != nil {
+		return err
+	}
+
+	return c.writer.Flush()
+}
+
+// Close closes the connection. TCP peers are not told why.
+func (c *tcpConn) Close() error {
+	return c.conn.Close()
+}
+
+// RemoteAddr returns the address of the peer
+func (c *tcpConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
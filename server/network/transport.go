@@ -0,0 +1,89 @@
+package network
+
+import (
+	"net"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Conn is a connection to a peer that carries client messages, whatever the transport underneath.
+// Messages are read and written without their framing; each Conn frames them as its transport requires.
+type Conn interface {
+	// ReadMessage blocks until the next message from the peer arrives
+	ReadMessage() ([]byte, error)
+
+	// WriteMessages sends messages to the peer, batched into as few writes as the transport allows
+	WriteMessages(messages [][]byte) error
+
+	// Ping checks that the peer is still there, keeping the connection alive
+	Ping() error
+
+	// Close says goodbye to the peer, if the transport has a way to, and closes the connection
+	Close() error
+
+	// RemoteAddr returns the address of the peer
+	RemoteAddr() net.Addr
+}
+
+// Transport accepts connections from peers over one kind of network connection, handing them to a hub.
+// All transports share the hub's message handlers and client lifecycle.
+type Transport interface {
+	// Listen accepts connections until the transport is closed or fails
+	Listen(hub *Hub) error
+
+	// Close stops accepting connections. Connections already accepted are left to the hub.
+	Close() error
+}
+
+// authenticate returns the name of the account a peer connects as, given either the token of a logged in
+// session or the resume token of a previous connection
+func (h *Hub) authenticate(token, resume string) (string, error) {
+	if resume != "" {
+		return h.resumes.Get(resume)
+	}
+
+	return h.config.Accounts.Authenticate(token)
+}
+
+// accept registers a new client for an authenticated peer with the hub, and starts pumping its messages.
+// Players must have their character loaded beforehand.
+func (h *Hub) accept(client *Client) {
+	log.WithFields(log.Fields{
+		"client":    client.conn.RemoteAddr().String(),
+		"name":      client.name,
+		"spectator": client.spectator,
+	}).Info("Client accepted")
+
+	select {
+	case h.register <- client:
+	case <-h.quit:
+		client.conn.Close()
+		return
+	}
+
+	// Start client message handler routines
+	go client.outboundHandler()
+	go client.inboundHandler()
+}
+
+// listenAll starts every transport, failing the server if one cannot listen
+func (h *Hub) listenAll() {
+	for _, transport := range h.transports {
+		go func(transport Transport) {
+			if err := transport.Listen(h); err != nil {
+				log.Fatal("Listen: ", err)
+			}
+		}(transport)
+	}
+}
+
+// closeAll stops every transport from accepting connections
+func (h *Hub) closeAll() {
+	for _, transport := range h.transports {
+		if err := transport.Close(); err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Warn("Failed to close transport")
+		}
+	}
+}
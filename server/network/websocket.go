@@ -0,0 +1,258 @@
+package network
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// websocketTransport serves clients over websockets, upgraded from requests to the webserver.
+// The webserver also serves the HTTP API, so closing the transport stops both.
+type websocketTransport struct {
+	// Settings used to serve clients
+	config *Config
+
+	// Upgrades websocket requests from allowed origins to connections
+	upgrader *websocket.Upgrader
+
+	// The webserver
+	server *http.Server
+}
+
+// newWebsocketTransport creates a transport accepting websocket connections on the webserver address
+func newWebsocketTransport(config *Config) *websocketTransport {
+	return &websocketTransport{
+		config: config,
+		upgrader: &websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     config.Origins.CheckOrigin,
+			Subprotocols:    subprotocols,
+		},
+		server: &http.Server{Addr: config.Address},
+	}
+}
+
+// Listen runs the webserver, serving TLS when configured to
+func (t *websocketTransport) Listen(hub *Hub) error {
+	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		t.handleWebsocketRequest(hub, w, r)
+	})
+
+	var err error
+	switch config := t.config; {
+	case len(config.AutocertHosts) > 0:
+		log.WithFields(log.Fields{
+			"address": config.Address,
+			"hosts":   config.AutocertHosts,
+		}).Info("Starting webserver; TLS with automatic certificates")
+
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(config.AutocertCacheDir),
+			HostPolicy: autocert.HostWhitelist(config.AutocertHosts...),
+			Email:      config.AutocertEmail,
+		}
+		// Answers tls-alpn-01 challenges on the TLS listener itself, so only this port needs to be reachable
+		t.server.TLSConfig = manager.TLSConfig()
+		err = t.server.ListenAndServeTLS("", "")
+	case config.TLSCertFile != "" || config.TLSKeyFile != "":
+		log.WithFields(log.Fields{
+			"address": config.Address,
+			"cert":    config.TLSCertFile,
+		}).Info("Starting webserver; TLS")
+
+		err = t.server.ListenAndServeTLS(config.TLSCertFile, config.TLSKeyFile)
+	default:
+		log.WithFields(log.Fields{
+			"address": config.Address,
+		}).Info("Starting webserver")
+
+		err = t.server.ListenAndServe()
+	}
+
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Close stops the webserver. Upgraded websocket connections are not closed.
+func (t *websocketTransport) Close() error {
+	return t.server.Close()
+}
+
+// handleWebsocketRequest negotiates initial websocket requests from peers.
+// Peers must pass the token of a logged in session as the "token" query parameter,
+// or the resume token of a previous connection as the "resume" query parameter.
+// Peers passing "spectate" as the "mode" query parameter watch the game as spectators, without a player.
+// Peers passing "admin" watch the same way and may also run admin commands, if their account is an admin.
+func (t *websocketTransport) handleWebsocketRequest(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	name, err := hub.authenticate(r.URL.Query().Get("token"), r.URL.Query().Get("resume"))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"address": r.RemoteAddr,
+			"error":   err,
+		}).Info("Websocket request refused; Not authenticated")
+
+		http.Error(w, "Unauthorized", 401)
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	if mode == "admin" && !hub.isAdmin(name) {
+		log.WithFields(log.Fields{
+			"address": r.RemoteAddr,
+			"name":    name,
+		}).Warn("Websocket request refused; Not an admin")
+
+		http.Error(w, "Forbidden", 403)
+		return
+	}
+
+	// Spectators and admins only watch, so have no character to load
+	spectate := mode == "spectate" || mode == "admin"
+	var character *characterState
+	if !spectate {
+		character, err = hub.persistence.load(name)
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			"address": r.RemoteAddr,
+			"name":    name,
+			"error":   err,
+		}).Error("Websocket request refused; Failed to load character")
+
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+
+	conn, err := t.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"address": r.RemoteAddr,
+			"error":   err,
+		}).Info("Websocket request refused; Upgrade failed")
+
+		return
+	}
+
+	if spectate {
+		client := newSpectator(hub, newWebsocketConn(conn), name)
+		client.admin = mode == "admin"
+		hub.accept(client)
+		return
+	}
+
+	client := NewClient(hub, newWebsocketConn(conn), name)
+	client.character = character
+	hub.accept(client)
+}
+
+// websocketConn carries client messages over a websocket connection.
+// Messages are framed as negotiated by websocket subprotocol, and several may be packed into one websocket message.
+type websocketConn struct {
+	conn    *websocket.Conn
+	framing framing
+
+	// Messages unpacked from the last websocket message that have not been read yet
+	unread [][]byte
+}
+
+// newWebsocketConn wraps an upgraded websocket connection, and starts expecting pongs from the peer
+func newWebsocketConn(conn *websocket.Conn) *websocketConn {
+	c := &websocketConn{
+		conn:    conn,
+		framing: framingForSubprotocol(conn.Subprotocol()),
+	}
+
+	conn.SetReadLimit(c.framing.readLimit())
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error { conn.SetReadDeadline(time.Now().Add(pongWait)); return nil })
+
+	return c
+}
+
+// ReadMessage returns the next message packed into websocket messages from the peer.
+// Non-binary websocket messages are ignored.
+func (c *websocketConn) ReadMessage() ([]byte, error) {
+	for len(c.unread) == 0 {
+		messageType, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+
+		// Only support binary messages
+		if messageType != websocket.BinaryMessage {
+			continue
+		}
+
+		// Keep whatever could be unpacked from a badly framed message
+		c.unread, err = c.framing.unpack(data)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"client":  c.conn.RemoteAddr().String(),
+				"framing": c.framing,
+				"error":   err,
+			}).Debug("Websocket read; Framing error")
+		}
+	}
+
+	message := c.unread[0]
+	c.unread = c.unread[1:]
+	return message, nil
+}
+
+// WriteMessages packs messages into a single websocket message.
+// Messages too large for the connection's framing are dropped.
+func (c *websocketConn) WriteMessages(messages [][]byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	w, err := c.conn.NextWriter(websocket.BinaryMessage)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		packed, err := c.framing.pack(message)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"client":  c.conn.RemoteAddr().String(),
+				"framing": c.framing,
+				"size":    len(message),
+				"error":   err,
+			}).Error("Websocket write; Framing error")
+
+			continue
+		}
+
+		if _, err := w.Write(packed); err != nil {
+			return err
+		}
+	}
+
+	// Flush write buffer to network layer
+	return w.Close()
+}
+
+// Ping sends a websocket ping. The peer's pong extends the read deadline.
+func (c *websocketConn) Ping() error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteMessage(websocket.PingMessage, []byte{})
+}
+
+// Close sends a websocket close message, then closes the connection.
+// Safe to call while another goroutine is reading or writing.
+func (c *websocketConn) Close() error {
+	c.conn.WriteControl(websocket.CloseMessage, []byte{}, time.Now().Add(writeWait))
+	return c.conn.Close()
+}
+
+// RemoteAddr returns the address of the peer
+func (c *websocketConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
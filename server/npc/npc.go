@@ -0,0 +1,138 @@
+package npc
+
+import (
+	"errors"
+	"sort"
+
+	bitflag "github.com/mvpninjas/go-bitflag"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/collision"
+	"bitbucket.org/ehhio/ehhworldserver/server/game"
+	"bitbucket.org/ehhio/ehhworldserver/server/object"
+	"bitbucket.org/ehhio/ehhworldserver/server/utility"
+)
+
+const npcCollisionSize = 0.5
+
+// Errors
+var (
+	// ErrUnknownKind is returned when creating an NPC of a kind that does not exist
+	ErrUnknownKind = errors.New("unknown NPC kind")
+)
+
+// Verify that *NPC implements Trackable
+var _ collision.Trackable = (*NPC)(nil)
+
+// Verify that *NPC implements IGameObject
+var _ game.IGameObject = (*NPC)(nil)
+
+// kind describes one kind of NPC
+type kind struct {
+	name   string
+	health int32
+	flags  []bitflag.Flag
+}
+
+// kinds are the kinds of NPC that can be created, by the name used to create them
+var kinds = map[string]kind{
+	"dummy": {name: "Training Dummy", health: 100, flags: []bitflag.Flag{object.FlagNPC, object.FlagImmovable, object.FlagInvulnerable}},
+}
+
+// NPC is a non-player character. NPCs stand where they are placed.
+type NPC struct {
+	id         uint32
+	kind       string
+	name       string
+	position   *utility.PositionHighResolution
+	health     int32
+	objectType *object.TypeFlagsBitSet
+}
+
+// NewNPC creates an NPC of a kind, such as "dummy".
+// ID is the unique entity ID of the NPC. Position sets where the NPC stands.
+func NewNPC(kindName string, id uint32, position *utility.PositionHighResolution) (*NPC, error) {
+	k, exists := kinds[kindName]
+	if !exists {
+		return nil, ErrUnknownKind
+	}
+
+	return &NPC{
+		id:         id,
+		kind:       kindName,
+		name:       k.name,
+		position:   position,
+		health:     k.health,
+		objectType: object.NewTypeFlagsBitSet(k.flags...),
+	}, nil
+}
+
+// Kinds returns the names of the kinds of NPC that can be created, sorted
+func Kinds() []string {
+	names := make([]string, 0, len(kinds))
+	for name := range kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// GetID returns the NPC's unique entity ID
+func (n *NPC) GetID() uint32 {
+	return n.id
+}
+
+// GetKind returns the name of the NPC's kind
+func (n *NPC) GetKind() string {
+	return n.kind
+}
+
+// GetName returns the NPC's name
+func (n *NPC) GetName() string {
+	return n.name
+}
+
+// GetPosition returns the NPC's position
+func (n *NPC) GetPosition() *utility.PositionHighResolution {
+	return n.position
+}
+
+// GetOrientation returns the direction the NPC is facing, in radians
+func (n *NPC) GetOrientation() float64 {
+	return 0
+}
+
+// GetHealth returns the NPC's health
+func (n *NPC) GetHealth() int32 {
+	return n.health
+}
+
+// IsLinkDead returns false, as NPCs have no connection to lose
+func (n *NPC) IsLinkDead() bool {
+	return false
+}
+
+// Update updates the NPC using a consistent time step
+func (n *NPC) Update(dt int64, g *game.Game) {
+
+}
+
+// Render renders the NPC to clients, interpolating state into the future
+func (n *NPC) Render(dt int64, g *game.Game) {
+
+}
+
+// GetAABBBottomLeftPoint returns the bottom left point of the NPC collision AABB, implementing collision.Trackable
+func (n *NPC) GetAABBBottomLeftPoint() *utility.PositionHighResolution {
+	return &utility.PositionHighResolution{X: n.position.X - npcCollisionSize/2.0, Y: n.position.Y - npcCollisionSize/2.0}
+}
+
+// GetAABBSize returns the width and height dimensions of the NPC collision AABB, implementing collision.Trackable
+func (n *NPC) GetAABBSize() *utility.SizeHighResolution {
+	return &utility.SizeHighResolution{Width: npcCollisionSize, Height: npcCollisionSize}
+}
+
+// GetObjectFlags returns the object type bit flags of the NPC, implementing collision.Trackable
+func (n *NPC) GetObjectFlags() *object.TypeFlagsBitSet {
+	return n.objectType
+}
@@ -16,6 +16,9 @@ const (
 
 	// FlagDirtyInventory flags a player as having an inventory change that needs to be sent to the client
 	FlagDirtyInventory
+
+	// FlagDirtyLinkDead flags a player as having lost or regained their connection, which needs to be sent to the client
+	FlagDirtyLinkDead
 )
 
 // DirtyFlagsBitSet is a bit set for various player-state-is-dirty flags, each a bit flag that is true or false
@@ -63,6 +63,27 @@ func (m *Minimap) GetMarker(key int) *MapMarker {
 	return m.markers[key]
 }
 
+// GetKnown returns the matrix of blocks the player is aware of across all sessions
+func (m *Minimap) GetKnown() *utility.BooleanMatrix {
+	return m.known
+}
+
+// SetKnown replaces the matrix of blocks the player is aware of across all sessions, such as with one saved by a previous session.
+// Returns false, leaving the minimap unchanged, if the matrix is not the size of the minimap.
+func (m *Minimap) SetKnown(known *utility.BooleanMatrix) bool {
+	if known.Size.Width != m.size.Width || known.Size.Height != m.size.Height {
+		return false
+	}
+
+	m.known = known
+	return true
+}
+
+// ResetExplored forgets which blocks have been explored, such as when the player starts a new session
+func (m *Minimap) ResetExplored() {
+	m.explored = utility.NewBooleanMatrix(m.size)
+}
+
 // ExploreUnknownWithin explores all unexplored map indexes within a certain distance of a point.
 // Returns a slice of these newly explored blocks
 func (m *Minimap) ExploreUnknownWithin(position *utility.Position, dx, dy int) []*utility.Position {
@@ -70,7 +91,24 @@ func (m *Minimap) ExploreUnknownWithin(position *utility.Position, dx, dy int) [
 		return make([]*utility.Position, 0)
 	}
 
-	return m.getAdjacentWithin(m.explored, position, dx, dy, false)
+	explored := m.getAdjacentWithin(m.explored, position, dx, dy, false)
+	for _, p := range explored {
+		m.known.Matrix[p.X][p.Y] = true
+	}
+
+	return explored
+}
+
+// Explore explores a single map index.
+// Returns true if the index was not explored before
+func (m *Minimap) Explore(position *utility.Position) bool {
+	if !position.IsWithinBounds(m.size) || m.explored.Matrix[position.X][position.Y] {
+		return false
+	}
+
+	m.explored.Matrix[position.X][position.Y] = true
+	m.known.Matrix[position.X][position.Y] = true
+	return true
 }
 
 // getAdjacentWithin returns adjacent indexs within a given distance that are the requested boolean
@@ -84,7 +122,7 @@ func (m *Minimap) getAdjacentWithin(target *utility.BooleanMatrix, position *uti
 	for x := xMin; x < xMax; x++ {
 		for y := yMin; y < yMax; y++ {
 			// Don't add ourselves
-			if x != position.X && y != position.Y {
+			if x != position.X || y != position.Y {
 				// Only add indexs that match the requested boolean state
 				if target.Matrix[x][y] == getThisBool {
 					target.Matrix[x][y] = !getThisBool
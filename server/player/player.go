@@ -1,6 +1,9 @@
 package player
 
 import (
+	"math"
+	"time"
+
 	"bitbucket.org/ehhio/ehhworldserver/server/collision"
 	"bitbucket.org/ehhio/ehhworldserver/server/game"
 	"bitbucket.org/ehhio/ehhworldserver/server/object"
@@ -9,6 +12,10 @@ import (
 )
 
 const playerCollisionSize = 0.1
+const playerSpeed = 4.0 // Cells per second
+
+// MaxHealth is the most health a player can have
+const MaxHealth = 100
 
 // Verify that Player implements Trackable
 var _ collision.Trackable = &Player{}
@@ -24,27 +31,43 @@ var _ game.IGameObject = (*Player)(nil)
 
 // Player is a struct defining a game object representing a remote game client
 type Player struct {
-	name       string
-	position   *utility.PositionHighResolution
-	Dirty      DirtyFlagsBitSet
-	minimap    *minimap.Minimap
-	objectType *object.TypeFlagsBitSet
+	id          uint32
+	name        string
+	position    *utility.PositionHighResolution
+	velocity    *utility.Vector // Cells per second
+	orientation float64         // Facing direction, in radians
+	health      int32
+	inventory   []*ItemStack
+	linkDead    bool // True while the player's client has lost its connection
+	Dirty       DirtyFlagsBitSet
+	minimap     *minimap.Minimap
+	objectType  *object.TypeFlagsBitSet
 }
 
 // NewPlayer creates a new player object.
-// Name names the player. Position sets the player's position. Size sets the size of the player's map.
-func NewPlayer(name string, position *utility.PositionHighResolution, size *utility.Size) *Player {
+// ID is the unique entity ID of the player. Name names the player. Position sets the player's position. Size sets the size of the player's map.
+func NewPlayer(id uint32, name string, position *utility.PositionHighResolution, size *utility.Size) *Player {
 	flags := object.NewTypeFlagsBitSet(object.FlagPlayer)
 	minimap := minimap.NewMinimap(size)
 	return &Player{
+		id:         id,
 		name:       name,
 		position:   position,
+		velocity:   &utility.Vector{},
+		health:     MaxHealth,
+		inventory:  make([]*ItemStack, 0),
 		Dirty:      DirtyFlagsBitSet{},
 		minimap:    minimap,
 		objectType: flags,
 	}
 }
 
+// ItemStack is a number of one type of object carried by a player
+type ItemStack struct {
+	ObjectID int
+	Count    int
+}
+
 // func (p *Player) IsDirty() bool {
 // 	return p.dirty.position || p.dirty.orientation || p.dirty.health
 // }
@@ -67,9 +90,113 @@ func NewPlayer(name string, position *utility.PositionHighResolution, size *util
 // 	p.minimap = minimap
 // }
 
+// GetID returns the unique entity ID of the player
+func (p *Player) GetID() uint32 {
+	return p.id
+}
+
+// GetName returns the name of the player
+func (p *Player) GetName() string {
+	return p.name
+}
+
+// GetPosition returns the position of the player in map space coordinates
+func (p *Player) GetPosition() *utility.PositionHighResolution {
+	return p.position
+}
+
+// GetOrientation returns the direction the player is facing, in radians
+func (p *Player) GetOrientation() float64 {
+	return p.orientation
+}
+
+// GetHealth returns the current health of the player
+func (p *Player) GetHealth() int32 {
+	return p.health
+}
+
+// SetHealth sets the current health of the player, within zero and the player's max health
+func (p *Player) SetHealth(health int32) {
+	if health < 0 {
+		health = 0
+	} else if health > MaxHealth {
+		health = MaxHealth
+	}
+
+	p.health = health
+}
+
+// GetInventory returns the stacks of objects carried by the player
+func (p *Player) GetInventory() []*ItemStack {
+	return p.inventory
+}
+
+// SetInventory replaces the stacks of objects carried by the player
+func (p *Player) SetInventory(inventory []*ItemStack) {
+	p.inventory = inventory
+}
+
+// IsLinkDead returns true while the player's client has lost its connection
+func (p *Player) IsLinkDead() bool {
+	return p.linkDead
+}
+
+// SetLinkDead marks the player as having lost or regained their client's connection.
+// A link-dead player stops moving, and lingers in the game waiting for the client to reconnect.
+func (p *Player) SetLinkDead(linkDead bool) {
+	if linkDead == p.linkDead {
+		return
+	}
+
+	p.linkDead = linkDead
+	if linkDead {
+		p.SetMoveDirection(&utility.Vector{})
+	}
+}
+
+// GetMinimap returns the player's minimap
+func (p *Player) GetMinimap() *minimap.Minimap {
+	return p.minimap
+}
+
+// SetMoveDirection sets the direction the player is trying to move in. A zero vector stops the player.
+func (p *Player) SetMoveDirection(direction *utility.Vector) {
+	p.velocity = direction.Normalize().Scale(playerSpeed)
+
+	if !p.velocity.IsZero() {
+		p.orientation = p.velocity.Angle()
+	}
+}
+
 // Update updates the player using a consistent time step
 func (p *Player) Update(dt int64, g *game.Game) {
+	if p.velocity.IsZero() {
+		return
+	}
+
+	// Integrate position over the time step, keeping the player inside the map
+	step := float64(dt) / float64(time.Second/time.Millisecond)
+	mapSize := g.GetGameMap().GetSize()
+	p.position = &utility.PositionHighResolution{
+		X: utility.ClampHighResolution(p.position.X+p.velocity.X*step, 0, math.Nextafter(float64(mapSize.Width), 0)),
+		Y: utility.ClampHighResolution(p.position.Y+p.velocity.Y*step, 0, math.Nextafter(float64(mapSize.Height), 0)),
+	}
+
+	// Keep the collision system in sync with our new position
+	g.GetCollision().UpdateObject(p)
+}
+
+// Teleport moves the player straight to a position, kept inside the map, and stops them.
+// Must only be called from the game loop, such as from a queued Command.
+func (p *Player) Teleport(position *utility.PositionHighResolution, g *game.Game) {
+	mapSize := g.GetGameMap().GetSize()
+	p.position = &utility.PositionHighResolution{
+		X: utility.ClampHighResolution(position.X, 0, math.Nextafter(float64(mapSize.Width), 0)),
+		Y: utility.ClampHighResolution(position.Y, 0, math.Nextafter(float64(mapSize.Height), 0)),
+	}
+	p.velocity = &utility.Vector{}
 
+	g.GetCollision().UpdateObject(p)
 }
 
 // Render renders the player to clients, interpolating state into the future
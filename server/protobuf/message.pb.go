@@ -9,10 +9,23 @@ It is generated from these files:
 
 It has these top-level messages:
 	Message
+	Hello
+	Welcome
+	Reject
+	Error
 	Move
 	Attack
 	Build
 	Sleep
+	Spectate
+	ChatSend
+	ChatReceive
+	AdminCommand
+	AdminResult
+	SnapshotAck
+	Snapshot
+	EntityState
+	BlockData
 */
 package protobuf
 
@@ -31,40 +44,98 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
-// ENUM Message types from peer
-type Message_Type int32
+// ChatChannel is who a chat message is for
+type ChatChannel int32
 
 const (
-	Message_NOOP   Message_Type = 0
-	Message_MOVE   Message_Type = 1
-	Message_ATTACK Message_Type = 2
-	Message_BUILD  Message_Type = 3
-	Message_SLEEP  Message_Type = 4
+	// Everyone in the game
+	ChatChannel_GLOBAL ChatChannel = 0
+	// Players near the sender
+	ChatChannel_LOCAL ChatChannel = 1
+	// One player, by name
+	ChatChannel_WHISPER ChatChannel = 2
+	// Announcements from the server's operators, to everyone
+	ChatChannel_SERVER ChatChannel = 3
 )
 
-var Message_Type_name = map[int32]string{
-	0: "NOOP",
-	1: "MOVE",
-	2: "ATTACK",
-	3: "BUILD",
-	4: "SLEEP",
+var ChatChannel_name = map[int32]string{
+	0: "GLOBAL",
+	1: "LOCAL",
+	2: "WHISPER",
+	3: "SERVER",
 }
-var Message_Type_value = map[string]int32{
-	"NOOP":   0,
-	"MOVE":   1,
-	"ATTACK": 2,
-	"BUILD":  3,
-	"SLEEP":  4,
+var ChatChannel_value = map[string]int32{
+	"GLOBAL":  0,
+	"LOCAL":   1,
+	"WHISPER": 2,
+	"SERVER":  3,
 }
 
-func (x Message_Type) String() string {
-	return proto.EnumName(Message_Type_name, int32(x))
+func (x ChatChannel) String() string {
+	return proto.EnumName(ChatChannel_name, int32(x))
 }
-func (Message_Type) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0, 0} }
+func (ChatChannel) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+type Error_Code int32
+
+const (
+	// Unspecified problem
+	Error_UNKNOWN Error_Code = 0
+	// The message could not be decoded
+	Error_MALFORMED Error_Code = 1
+	// The message has no payload, or a payload the server does not handle
+	Error_UNSUPPORTED Error_Code = 2
+	// The message was sent before completing the handshake
+	Error_HANDSHAKE_REQUIRED Error_Code = 3
+	// The client sent too many messages, which were dropped
+	Error_RATE_LIMITED Error_Code = 4
+	// The message was larger than its payload type allows
+	Error_TOO_LARGE Error_Code = 5
+	// The message is not allowed in the client's connection mode, such as gameplay from a spectator
+	Error_WRONG_MODE Error_Code = 6
+	// The message names something that does not exist, such as a player that is not in the game
+	Error_NOT_FOUND Error_Code = 7
+	// The message content is not allowed, such as empty or filtered chat
+	Error_INVALID Error_Code = 8
+	// The client is not allowed to send the message, such as admin commands from players
+	Error_FORBIDDEN Error_Code = 9
+	// The client was disconnected by an operator
+	Error_KICKED Error_Code = 10
+)
+
+var Error_Code_name = map[int32]string{
+	0:  "UNKNOWN",
+	1:  "MALFORMED",
+	2:  "UNSUPPORTED",
+	3:  "HANDSHAKE_REQUIRED",
+	4:  "RATE_LIMITED",
+	5:  "TOO_LARGE",
+	6:  "WRONG_MODE",
+	7:  "NOT_FOUND",
+	8:  "INVALID",
+	9:  "FORBIDDEN",
+	10: "KICKED",
+}
+var Error_Code_value = map[string]int32{
+	"UNKNOWN":            0,
+	"MALFORMED":          1,
+	"UNSUPPORTED":        2,
+	"HANDSHAKE_REQUIRED": 3,
+	"RATE_LIMITED":       4,
+	"TOO_LARGE":          5,
+	"WRONG_MODE":         6,
+	"NOT_FOUND":          7,
+	"INVALID":            8,
+	"FORBIDDEN":          9,
+	"KICKED":             10,
+}
+
+func (x Error_Code) String() string {
+	return proto.EnumName(Error_Code_name, int32(x))
+}
+func (Error_Code) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{4, 0} }
 
 type Message struct {
-	// Message type
-	Type Message_Type `protobuf:"varint,1,opt,name=type,enum=protobuf.Message_Type" json:"type,omitempty"`
 	// Message payload
 	//
 	// Types that are valid to be assigned to Payload:
@@ -72,6 +143,18 @@ type Message struct {
 	//	*Message_Attack
 	//	*Message_Build
 	//	*Message_Sleep
+	//	*Message_SnapshotAck
+	//	*Message_Hello
+	//	*Message_Spectate
+	//	*Message_ChatSend
+	//	*Message_AdminCommand
+	//	*Message_Snapshot
+	//	*Message_BlockData
+	//	*Message_Welcome
+	//	*Message_Reject
+	//	*Message_Error
+	//	*Message_ChatReceive
+	//	*Message_AdminResult
 	Payload isMessage_Payload `protobuf_oneof:"payload"`
 }
 
@@ -96,11 +179,59 @@ type Message_Build struct {
 type Message_Sleep struct {
 	Sleep *Sleep `protobuf:"bytes,13,opt,name=sleep,oneof"`
 }
+type Message_SnapshotAck struct {
+	SnapshotAck *SnapshotAck `protobuf:"bytes,14,opt,name=snapshot_ack,json=snapshotAck,oneof"`
+}
+type Message_Hello struct {
+	Hello *Hello `protobuf:"bytes,15,opt,name=hello,oneof"`
+}
+type Message_Spectate struct {
+	Spectate *Spectate `protobuf:"bytes,16,opt,name=spectate,oneof"`
+}
+type Message_ChatSend struct {
+	ChatSend *ChatSend `protobuf:"bytes,17,opt,name=chat_send,json=chatSend,oneof"`
+}
+type Message_AdminCommand struct {
+	AdminCommand *AdminCommand `protobuf:"bytes,18,opt,name=admin_command,json=adminCommand,oneof"`
+}
+type Message_Snapshot struct {
+	Snapshot *Snapshot `protobuf:"bytes,20,opt,name=snapshot,oneof"`
+}
+type Message_BlockData struct {
+	BlockData *BlockData `protobuf:"bytes,21,opt,name=block_data,json=blockData,oneof"`
+}
+type Message_Welcome struct {
+	Welcome *Welcome `protobuf:"bytes,22,opt,name=welcome,oneof"`
+}
+type Message_Reject struct {
+	Reject *Reject `protobuf:"bytes,23,opt,name=reject,oneof"`
+}
+type Message_Error struct {
+	Error *Error `protobuf:"bytes,24,opt,name=error,oneof"`
+}
+type Message_ChatReceive struct {
+	ChatReceive *ChatReceive `protobuf:"bytes,25,opt,name=chat_receive,json=chatReceive,oneof"`
+}
+type Message_AdminResult struct {
+	AdminResult *AdminResult `protobuf:"bytes,26,opt,name=admin_result,json=adminResult,oneof"`
+}
 
-func (*Message_Move) isMessage_Payload()   {}
-func (*Message_Attack) isMessage_Payload() {}
-func (*Message_Build) isMessage_Payload()  {}
-func (*Message_Sleep) isMessage_Payload()  {}
+func (*Message_Move) isMessage_Payload()         {}
+func (*Message_Attack) isMessage_Payload()       {}
+func (*Message_Build) isMessage_Payload()        {}
+func (*Message_Sleep) isMessage_Payload()        {}
+func (*Message_SnapshotAck) isMessage_Payload()  {}
+func (*Message_Hello) isMessage_Payload()        {}
+func (*Message_Spectate) isMessage_Payload()     {}
+func (*Message_ChatSend) isMessage_Payload()     {}
+func (*Message_AdminCommand) isMessage_Payload() {}
+func (*Message_Snapshot) isMessage_Payload()     {}
+func (*Message_BlockData) isMessage_Payload()    {}
+func (*Message_Welcome) isMessage_Payload()      {}
+func (*Message_Reject) isMessage_Payload()       {}
+func (*Message_Error) isMessage_Payload()        {}
+func (*Message_ChatReceive) isMessage_Payload()  {}
+func (*Message_AdminResult) isMessage_Payload()  {}
 
 func (m *Message) GetPayload() isMessage_Payload {
 	if m != nil {
@@ -109,13 +240,6 @@ func (m *Message) GetPayload() isMessage_Payload {
 	return nil
 }
 
-func (m *Message) GetType() Message_Type {
-	if m != nil {
-		return m.Type
-	}
-	return Message_NOOP
-}
-
 func (m *Message) GetMove() *Move {
 	if x, ok := m.GetPayload().(*Message_Move); ok {
 		return x.Move
@@ -144,6 +268,90 @@ func (m *Message) GetSleep() *Sleep {
 	return nil
 }
 
+func (m *Message) GetSnapshotAck() *SnapshotAck {
+	if x, ok := m.GetPayload().(*Message_SnapshotAck); ok {
+		return x.SnapshotAck
+	}
+	return nil
+}
+
+func (m *Message) GetHello() *Hello {
+	if x, ok := m.GetPayload().(*Message_Hello); ok {
+		return x.Hello
+	}
+	return nil
+}
+
+func (m *Message) GetSpectate() *Spectate {
+	if x, ok := m.GetPayload().(*Message_Spectate); ok {
+		return x.Spectate
+	}
+	return nil
+}
+
+func (m *Message) GetChatSend() *ChatSend {
+	if x, ok := m.GetPayload().(*Message_ChatSend); ok {
+		return x.ChatSend
+	}
+	return nil
+}
+
+func (m *Message) GetAdminCommand() *AdminCommand {
+	if x, ok := m.GetPayload().(*Message_AdminCommand); ok {
+		return x.AdminCommand
+	}
+	return nil
+}
+
+func (m *Message) GetSnapshot() *Snapshot {
+	if x, ok := m.GetPayload().(*Message_Snapshot); ok {
+		return x.Snapshot
+	}
+	return nil
+}
+
+func (m *Message) GetBlockData() *BlockData {
+	if x, ok := m.GetPayload().(*Message_BlockData); ok {
+		return x.BlockData
+	}
+	return nil
+}
+
+func (m *Message) GetWelcome() *Welcome {
+	if x, ok := m.GetPayload().(*Message_Welcome); ok {
+		return x.Welcome
+	}
+	return nil
+}
+
+func (m *Message) GetReject() *Reject {
+	if x, ok := m.GetPayload().(*Message_Reject); ok {
+		return x.Reject
+	}
+	return nil
+}
+
+func (m *Message) GetError() *Error {
+	if x, ok := m.GetPayload().(*Message_Error); ok {
+		return x.Error
+	}
+	return nil
+}
+
+func (m *Message) GetChatReceive() *ChatReceive {
+	if x, ok := m.GetPayload().(*Message_ChatReceive); ok {
+		return x.ChatReceive
+	}
+	return nil
+}
+
+func (m *Message) GetAdminResult() *AdminResult {
+	if x, ok := m.GetPayload().(*Message_AdminResult); ok {
+		return x.AdminResult
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*Message) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _Message_OneofMarshaler, _Message_OneofUnmarshaler, _Message_OneofSizer, []interface{}{
@@ -151,6 +359,18 @@ func (*Message) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error
 		(*Message_Attack)(nil),
 		(*Message_Build)(nil),
 		(*Message_Sleep)(nil),
+		(*Message_SnapshotAck)(nil),
+		(*Message_Hello)(nil),
+		(*Message_Spectate)(nil),
+		(*Message_ChatSend)(nil),
+		(*Message_AdminCommand)(nil),
+		(*Message_Snapshot)(nil),
+		(*Message_BlockData)(nil),
+		(*Message_Welcome)(nil),
+		(*Message_Reject)(nil),
+		(*Message_Error)(nil),
+		(*Message_ChatReceive)(nil),
+		(*Message_AdminResult)(nil),
 	}
 }
 
@@ -178,6 +398,66 @@ func _Message_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.Sleep); err != nil {
 			return err
 		}
+	case *Message_SnapshotAck:
+		b.EncodeVarint(14<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.SnapshotAck); err != nil {
+			return err
+		}
+	case *Message_Hello:
+		b.EncodeVarint(15<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Hello); err != nil {
+			return err
+		}
+	case *Message_Spectate:
+		b.EncodeVarint(16<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Spectate); err != nil {
+			return err
+		}
+	case *Message_ChatSend:
+		b.EncodeVarint(17<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.ChatSend); err != nil {
+			return err
+		}
+	case *Message_AdminCommand:
+		b.EncodeVarint(18<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.AdminCommand); err != nil {
+			return err
+		}
+	case *Message_Snapshot:
+		b.EncodeVarint(20<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Snapshot); err != nil {
+			return err
+		}
+	case *Message_BlockData:
+		b.EncodeVarint(21<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BlockData); err != nil {
+			return err
+		}
+	case *Message_Welcome:
+		b.EncodeVarint(22<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Welcome); err != nil {
+			return err
+		}
+	case *Message_Reject:
+		b.EncodeVarint(23<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Reject); err != nil {
+			return err
+		}
+	case *Message_Error:
+		b.EncodeVarint(24<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Error); err != nil {
+			return err
+		}
+	case *Message_ChatReceive:
+		b.EncodeVarint(25<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.ChatReceive); err != nil {
+			return err
+		}
+	case *Message_AdminResult:
+		b.EncodeVarint(26<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.AdminResult); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("Message.Payload has unexpected type %T", x)
@@ -220,6 +500,102 @@ func _Message_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer
 		err := b.DecodeMessage(msg)
 		m.Payload = &Message_Sleep{msg}
 		return true, err
+	case 14: // payload.snapshot_ack
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(SnapshotAck)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_SnapshotAck{msg}
+		return true, err
+	case 15: // payload.hello
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Hello)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_Hello{msg}
+		return true, err
+	case 16: // payload.spectate
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Spectate)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_Spectate{msg}
+		return true, err
+	case 17: // payload.chat_send
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(ChatSend)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_ChatSend{msg}
+		return true, err
+	case 18: // payload.admin_command
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(AdminCommand)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_AdminCommand{msg}
+		return true, err
+	case 20: // payload.snapshot
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Snapshot)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_Snapshot{msg}
+		return true, err
+	case 21: // payload.block_data
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BlockData)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_BlockData{msg}
+		return true, err
+	case 22: // payload.welcome
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Welcome)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_Welcome{msg}
+		return true, err
+	case 23: // payload.reject
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Reject)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_Reject{msg}
+		return true, err
+	case 24: // payload.error
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Error)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_Error{msg}
+		return true, err
+	case 25: // payload.chat_receive
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(ChatReceive)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_ChatReceive{msg}
+		return true, err
+	case 26: // payload.admin_result
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(AdminResult)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_AdminResult{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -249,6 +625,66 @@ func _Message_OneofSizer(msg proto.Message) (n int) {
 		n += proto.SizeVarint(13<<3 | proto.WireBytes)
 		n += proto.SizeVarint(uint64(s))
 		n += s
+	case *Message_SnapshotAck:
+		s := proto.Size(x.SnapshotAck)
+		n += proto.SizeVarint(14<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_Hello:
+		s := proto.Size(x.Hello)
+		n += proto.SizeVarint(15<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_Spectate:
+		s := proto.Size(x.Spectate)
+		n += proto.SizeVarint(16<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_ChatSend:
+		s := proto.Size(x.ChatSend)
+		n += proto.SizeVarint(17<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_AdminCommand:
+		s := proto.Size(x.AdminCommand)
+		n += proto.SizeVarint(18<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_Snapshot:
+		s := proto.Size(x.Snapshot)
+		n += proto.SizeVarint(20<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_BlockData:
+		s := proto.Size(x.BlockData)
+		n += proto.SizeVarint(21<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_Welcome:
+		s := proto.Size(x.Welcome)
+		n += proto.SizeVarint(22<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_Reject:
+		s := proto.Size(x.Reject)
+		n += proto.SizeVarint(23<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_Error:
+		s := proto.Size(x.Error)
+		n += proto.SizeVarint(24<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_ChatReceive:
+		s := proto.Size(x.ChatReceive)
+		n += proto.SizeVarint(25<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_AdminResult:
+		s := proto.Size(x.AdminResult)
+		n += proto.SizeVarint(26<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -256,14 +692,238 @@ func _Message_OneofSizer(msg proto.Message) (n int) {
 	return n
 }
 
+// Hello opens the connection handshake, sent by the client
+type Hello struct {
+	// Protocol version the client speaks
+	ProtocolVersion uint32 `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion" json:"protocol_version,omitempty"`
+	// Session token from logging in, or resume token from a previous Welcome.
+	// Only read from connections without a handshake of their own to carry them, such as plain TCP.
+	Token       string `protobuf:"bytes,2,opt,name=token" json:"token,omitempty"`
+	ResumeToken string `protobuf:"bytes,3,opt,name=resume_token,json=resumeToken" json:"resume_token,omitempty"`
+	// Whether to watch as a spectator rather than play. Connections without a handshake of their own set this
+	// instead of connecting to /ws?mode=spectate.
+	Spectate bool `protobuf:"varint,4,opt,name=spectate" json:"spectate,omitempty"`
+}
+
+func (m *Hello) Reset()                    { *m = Hello{} }
+func (m *Hello) String() string            { return proto.CompactTextString(m) }
+func (*Hello) ProtoMessage()               {}
+func (*Hello) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *Hello) GetProtocolVersion() uint32 {
+	if m != nil {
+		return m.ProtocolVersion
+	}
+	return 0
+}
+
+func (m *Hello) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *Hello) GetResumeToken() string {
+	if m != nil {
+		return m.ResumeToken
+	}
+	return ""
+}
+
+func (m *Hello) GetSpectate() bool {
+	if m != nil {
+		return m.Spectate
+	}
+	return false
+}
+
+// Welcome accepts a client's Hello, sent by the server
+type Welcome struct {
+	// Protocol version the connection will use
+	ProtocolVersion uint32 `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion" json:"protocol_version,omitempty"`
+	// Entity ID and name of the client's player
+	EntityId   uint32 `protobuf:"varint,2,opt,name=entity_id,json=entityId" json:"entity_id,omitempty"`
+	PlayerName string `protobuf:"bytes,3,opt,name=player_name,json=playerName" json:"player_name,omitempty"`
+	// Size of the map, in cells
+	MapWidth  int32 `protobuf:"varint,4,opt,name=map_width,json=mapWidth" json:"map_width,omitempty"`
+	MapHeight int32 `protobuf:"varint,5,opt,name=map_height,json=mapHeight" json:"map_height,omitempty"`
+	// Size of map blocks, in cells
+	BlockWidth  int32 `protobuf:"varint,6,opt,name=block_width,json=blockWidth" json:"block_width,omitempty"`
+	BlockHeight int32 `protobuf:"varint,7,opt,name=block_height,json=blockHeight" json:"block_height,omitempty"`
+	// Seed the map was generated with
+	Seed int64 `protobuf:"varint,8,opt,name=seed" json:"seed,omitempty"`
+	// Times per second the game ticks, and sends snapshots
+	TickRate int32 `protobuf:"varint,9,opt,name=tick_rate,json=tickRate" json:"tick_rate,omitempty"`
+	SendRate int32 `protobuf:"varint,10,opt,name=send_rate,json=sendRate" json:"send_rate,omitempty"`
+	// Token to reconnect with, as the "resume" websocket query parameter, to reattach to the player after a dropped connection
+	ResumeToken string `protobuf:"bytes,11,opt,name=resume_token,json=resumeToken" json:"resume_token,omitempty"`
+	// True if the connection reattached to a player that was already in the game
+	Resumed bool `protobuf:"varint,12,opt,name=resumed" json:"resumed,omitempty"`
+	// True if the client is a spectator, with no player of its own. entity_id and player_name are then empty.
+	Spectating bool `protobuf:"varint,13,opt,name=spectating" json:"spectating,omitempty"`
+}
+
+func (m *Welcome) Reset()                    { *m = Welcome{} }
+func (m *Welcome) String() string            { return proto.CompactTextString(m) }
+func (*Welcome) ProtoMessage()               {}
+func (*Welcome) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *Welcome) GetProtocolVersion() uint32 {
+	if m != nil {
+		return m.ProtocolVersion
+	}
+	return 0
+}
+
+func (m *Welcome) GetEntityId() uint32 {
+	if m != nil {
+		return m.EntityId
+	}
+	return 0
+}
+
+func (m *Welcome) GetPlayerName() string {
+	if m != nil {
+		return m.PlayerName
+	}
+	return ""
+}
+
+func (m *Welcome) GetMapWidth() int32 {
+	if m != nil {
+		return m.MapWidth
+	}
+	return 0
+}
+
+func (m *Welcome) GetMapHeight() int32 {
+	if m != nil {
+		return m.MapHeight
+	}
+	return 0
+}
+
+func (m *Welcome) GetBlockWidth() int32 {
+	if m != nil {
+		return m.BlockWidth
+	}
+	return 0
+}
+
+func (m *Welcome) GetBlockHeight() int32 {
+	if m != nil {
+		return m.BlockHeight
+	}
+	return 0
+}
+
+func (m *Welcome) GetSeed() int64 {
+	if m != nil {
+		return m.Seed
+	}
+	return 0
+}
+
+func (m *Welcome) GetTickRate() int32 {
+	if m != nil {
+		return m.TickRate
+	}
+	return 0
+}
+
+func (m *Welcome) GetSendRate() int32 {
+	if m != nil {
+		return m.SendRate
+	}
+	return 0
+}
+
+func (m *Welcome) GetResumeToken() string {
+	if m != nil {
+		return m.ResumeToken
+	}
+	return ""
+}
+
+func (m *Welcome) GetResumed() bool {
+	if m != nil {
+		return m.Resumed
+	}
+	return false
+}
+
+func (m *Welcome) GetSpectating() bool {
+	if m != nil {
+		return m.Spectating
+	}
+	return false
+}
+
+// Reject refuses a client's Hello, sent by the server before closing the connection
+type Reject struct {
+	// Human readable reason for the rejection
+	Reason string `protobuf:"bytes,1,opt,name=reason" json:"reason,omitempty"`
+	// Protocol version the server speaks
+	ProtocolVersion uint32 `protobuf:"varint,2,opt,name=protocol_version,json=protocolVersion" json:"protocol_version,omitempty"`
+}
+
+func (m *Reject) Reset()                    { *m = Reject{} }
+func (m *Reject) String() string            { return proto.CompactTextString(m) }
+func (*Reject) ProtoMessage()               {}
+func (*Reject) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *Reject) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *Reject) GetProtocolVersion() uint32 {
+	if m != nil {
+		return m.ProtocolVersion
+	}
+	return 0
+}
+
+// Error reports a problem with a message from the client, sent by the server
+type Error struct {
+	Code Error_Code `protobuf:"varint,1,opt,name=code,enum=protobuf.Error_Code" json:"code,omitempty"`
+	// Human readable description of the problem
+	Reason string `protobuf:"bytes,2,opt,name=reason" json:"reason,omitempty"`
+}
+
+func (m *Error) Reset()                    { *m = Error{} }
+func (m *Error) String() string            { return proto.CompactTextString(m) }
+func (*Error) ProtoMessage()               {}
+func (*Error) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *Error) GetCode() Error_Code {
+	if m != nil {
+		return m.Code
+	}
+	return Error_UNKNOWN
+}
+
+func (m *Error) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
 type Move struct {
+	// Direction to move in, made up of "Up", "Down", "Left" and "Right" (e.g. "UpLeft"). Empty to stop.
 	Direction string `protobuf:"bytes,1,opt,name=direction" json:"direction,omitempty"`
+	// Client input sequence number, echoed back in snapshots once processed
+	Sequence uint32 `protobuf:"varint,2,opt,name=sequence" json:"sequence,omitempty"`
 }
 
 func (m *Move) Reset()                    { *m = Move{} }
 func (m *Move) String() string            { return proto.CompactTextString(m) }
 func (*Move) ProtoMessage()               {}
-func (*Move) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+func (*Move) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
 
 func (m *Move) GetDirection() string {
 	if m != nil {
@@ -272,14 +932,23 @@ func (m *Move) GetDirection() string {
 	return ""
 }
 
+func (m *Move) GetSequence() uint32 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
 type Attack struct {
 	Target string `protobuf:"bytes,1,opt,name=target" json:"target,omitempty"`
+	// Client input sequence number, echoed back in snapshots once processed
+	Sequence uint32 `protobuf:"varint,2,opt,name=sequence" json:"sequence,omitempty"`
 }
 
 func (m *Attack) Reset()                    { *m = Attack{} }
 func (m *Attack) String() string            { return proto.CompactTextString(m) }
 func (*Attack) ProtoMessage()               {}
-func (*Attack) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+func (*Attack) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
 
 func (m *Attack) GetTarget() string {
 	if m != nil {
@@ -288,14 +957,23 @@ func (m *Attack) GetTarget() string {
 	return ""
 }
 
+func (m *Attack) GetSequence() uint32 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
 type Build struct {
 	Type string `protobuf:"bytes,1,opt,name=type" json:"type,omitempty"`
+	// Client input sequence number, echoed back in snapshots once processed
+	Sequence uint32 `protobuf:"varint,2,opt,name=sequence" json:"sequence,omitempty"`
 }
 
 func (m *Build) Reset()                    { *m = Build{} }
 func (m *Build) String() string            { return proto.CompactTextString(m) }
 func (*Build) ProtoMessage()               {}
-func (*Build) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+func (*Build) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
 
 func (m *Build) GetType() string {
 	if m != nil {
@@ -304,6 +982,13 @@ func (m *Build) GetType() string {
 	return ""
 }
 
+func (m *Build) GetSequence() uint32 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
 type Sleep struct {
 	Duration string `protobuf:"bytes,1,opt,name=duration" json:"duration,omitempty"`
 }
@@ -311,7 +996,7 @@ type Sleep struct {
 func (m *Sleep) Reset()                    { *m = Sleep{} }
 func (m *Sleep) String() string            { return proto.CompactTextString(m) }
 func (*Sleep) ProtoMessage()               {}
-func (*Sleep) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (*Sleep) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
 
 func (m *Sleep) GetDuration() string {
 	if m != nil {
@@ -320,37 +1005,518 @@ func (m *Sleep) GetDuration() string {
 	return ""
 }
 
+// Spectate moves a spectator's camera, sent by spectating clients.
+// The camera centers on the player named by follow, or on x and y when follow is empty.
+type Spectate struct {
+	X      float64 `protobuf:"fixed64,1,opt,name=x" json:"x,omitempty"`
+	Y      float64 `protobuf:"fixed64,2,opt,name=y" json:"y,omitempty"`
+	Follow string  `protobuf:"bytes,3,opt,name=follow" json:"follow,omitempty"`
+}
+
+func (m *Spectate) Reset()                    { *m = Spectate{} }
+func (m *Spectate) String() string            { return proto.CompactTextString(m) }
+func (*Spectate) ProtoMessage()               {}
+func (*Spectate) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *Spectate) GetX() float64 {
+	if m != nil {
+		return m.X
+	}
+	return 0
+}
+
+func (m *Spectate) GetY() float64 {
+	if m != nil {
+		return m.Y
+	}
+	return 0
+}
+
+func (m *Spectate) GetFollow() string {
+	if m != nil {
+		return m.Follow
+	}
+	return ""
+}
+
+// ChatSend says something in chat, sent by the client
+type ChatSend struct {
+	Channel ChatChannel `protobuf:"varint,1,opt,name=channel,enum=protobuf.ChatChannel" json:"channel,omitempty"`
+	Text    string      `protobuf:"bytes,2,opt,name=text" json:"text,omitempty"`
+	// Name of the player to whisper to
+	To string `protobuf:"bytes,3,opt,name=to" json:"to,omitempty"`
+}
+
+func (m *ChatSend) Reset()                    { *m = ChatSend{} }
+func (m *ChatSend) String() string            { return proto.CompactTextString(m) }
+func (*ChatSend) ProtoMessage()               {}
+func (*ChatSend) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *ChatSend) GetChannel() ChatChannel {
+	if m != nil {
+		return m.Channel
+	}
+	return ChatChannel_GLOBAL
+}
+
+func (m *ChatSend) GetText() string {
+	if m != nil {
+		return m.Text
+	}
+	return ""
+}
+
+func (m *ChatSend) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+// ChatReceive delivers a chat message, sent by the server.
+// Whispers are echoed back to their sender, with to naming who they were sent to.
+type ChatReceive struct {
+	Channel ChatChannel `protobuf:"varint,1,opt,name=channel,enum=protobuf.ChatChannel" json:"channel,omitempty"`
+	Text    string      `protobuf:"bytes,2,opt,name=text" json:"text,omitempty"`
+	// Name and entity ID of the player who said it
+	From   string `protobuf:"bytes,3,opt,name=from" json:"from,omitempty"`
+	FromId uint32 `protobuf:"varint,4,opt,name=from_id,json=fromId" json:"from_id,omitempty"`
+	// Name of the player a whisper was sent to
+	To string `protobuf:"bytes,5,opt,name=to" json:"to,omitempty"`
+}
+
+func (m *ChatReceive) Reset()                    { *m = ChatReceive{} }
+func (m *ChatReceive) String() string            { return proto.CompactTextString(m) }
+func (*ChatReceive) ProtoMessage()               {}
+func (*ChatReceive) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *ChatReceive) GetChannel() ChatChannel {
+	if m != nil {
+		return m.Channel
+	}
+	return ChatChannel_GLOBAL
+}
+
+func (m *ChatReceive) GetText() string {
+	if m != nil {
+		return m.Text
+	}
+	return ""
+}
+
+func (m *ChatReceive) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *ChatReceive) GetFromId() uint32 {
+	if m != nil {
+		return m.FromId
+	}
+	return 0
+}
+
+func (m *ChatReceive) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+// AdminCommand runs an admin console command, such as "kick alice", sent by admin clients
+type AdminCommand struct {
+	Command string `protobuf:"bytes,1,opt,name=command" json:"command,omitempty"`
+}
+
+func (m *AdminCommand) Reset()                    { *m = AdminCommand{} }
+func (m *AdminCommand) String() string            { return proto.CompactTextString(m) }
+func (*AdminCommand) ProtoMessage()               {}
+func (*AdminCommand) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *AdminCommand) GetCommand() string {
+	if m != nil {
+		return m.Command
+	}
+	return ""
+}
+
+// AdminResult is the output of an admin command that ran, sent by the server.
+// Commands that fail are answered with an Error instead.
+type AdminResult struct {
+	Command string `protobuf:"bytes,1,opt,name=command" json:"command,omitempty"`
+	Output  string `protobuf:"bytes,2,opt,name=output" json:"output,omitempty"`
+}
+
+func (m *AdminResult) Reset()                    { *m = AdminResult{} }
+func (m *AdminResult) String() string            { return proto.CompactTextString(m) }
+func (*AdminResult) ProtoMessage()               {}
+func (*AdminResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *AdminResult) GetCommand() string {
+	if m != nil {
+		return m.Command
+	}
+	return ""
+}
+
+func (m *AdminResult) GetOutput() string {
+	if m != nil {
+		return m.Output
+	}
+	return ""
+}
+
+// SnapshotAck acknowledges a received snapshot, sent by the client
+type SnapshotAck struct {
+	// Tick of the received snapshot
+	Tick uint32 `protobuf:"varint,1,opt,name=tick" json:"tick,omitempty"`
+}
+
+func (m *SnapshotAck) Reset()                    { *m = SnapshotAck{} }
+func (m *SnapshotAck) String() string            { return proto.CompactTextString(m) }
+func (*SnapshotAck) ProtoMessage()               {}
+func (*SnapshotAck) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *SnapshotAck) GetTick() uint32 {
+	if m != nil {
+		return m.Tick
+	}
+	return 0
+}
+
+// Snapshot of the game state around a client, sent by the server
+type Snapshot struct {
+	// Game tick the snapshot was taken on, which counts up without wrapping
+	Tick uint32 `protobuf:"varint,1,opt,name=tick" json:"tick,omitempty"`
+	// Entities near the client. In a delta snapshot, only entities that changed since the base snapshot
+	Entities []*EntityState `protobuf:"bytes,2,rep,name=entities" json:"entities,omitempty"`
+	// True if the snapshot only holds changes since the base snapshot
+	Delta bool `protobuf:"varint,3,opt,name=delta" json:"delta,omitempty"`
+	// Tick of the acknowledged snapshot a delta snapshot was encoded against
+	BaseTick uint32 `protobuf:"varint,4,opt,name=base_tick,json=baseTick" json:"base_tick,omitempty"`
+	// IDs of entities in the base snapshot that are no longer near the client
+	Removed []uint32 `protobuf:"varint,5,rep,packed,name=removed" json:"removed,omitempty"`
+	// IDs of entities that came into the client's view since the previous snapshot. Their full state is in entities.
+	Entered []uint32 `protobuf:"varint,6,rep,packed,name=entered" json:"entered,omitempty"`
+	// IDs of entities that left the client's view since the previous snapshot
+	Left []uint32 `protobuf:"varint,7,rep,packed,name=left" json:"left,omitempty"`
+	// Sequence number of the last client input processed before the snapshot was taken
+	AckSequence uint32 `protobuf:"varint,8,opt,name=ack_sequence,json=ackSequence" json:"ack_sequence,omitempty"`
+}
+
+func (m *Snapshot) Reset()                    { *m = Snapshot{} }
+func (m *Snapshot) String() string            { return proto.CompactTextString(m) }
+func (*Snapshot) ProtoMessage()               {}
+func (*Snapshot) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *Snapshot) GetTick() uint32 {
+	if m != nil {
+		return m.Tick
+	}
+	return 0
+}
+
+func (m *Snapshot) GetEntities() []*EntityState {
+	if m != nil {
+		return m.Entities
+	}
+	return nil
+}
+
+func (m *Snapshot) GetDelta() bool {
+	if m != nil {
+		return m.Delta
+	}
+	return false
+}
+
+func (m *Snapshot) GetBaseTick() uint32 {
+	if m != nil {
+		return m.BaseTick
+	}
+	return 0
+}
+
+func (m *Snapshot) GetRemoved() []uint32 {
+	if m != nil {
+		return m.Removed
+	}
+	return nil
+}
+
+func (m *Snapshot) GetEntered() []uint32 {
+	if m != nil {
+		return m.Entered
+	}
+	return nil
+}
+
+func (m *Snapshot) GetLeft() []uint32 {
+	if m != nil {
+		return m.Left
+	}
+	return nil
+}
+
+func (m *Snapshot) GetAckSequence() uint32 {
+	if m != nil {
+		return m.AckSequence
+	}
+	return 0
+}
+
+// EntityState is the replicated state of a single game entity
+type EntityState struct {
+	Id          uint32  `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Name        string  `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	X           float64 `protobuf:"fixed64,3,opt,name=x" json:"x,omitempty"`
+	Y           float64 `protobuf:"fixed64,4,opt,name=y" json:"y,omitempty"`
+	Orientation float64 `protobuf:"fixed64,5,opt,name=orientation" json:"orientation,omitempty"`
+	Health      int32   `protobuf:"varint,6,opt,name=health" json:"health,omitempty"`
+	// Bit flags of the fields that are set (1 = position, 2 = orientation, 4 = health, 8 = inventory, 16 = link_dead).
+	// Full entity states, which also carry the name, have all flags set.
+	Changed uint32 `protobuf:"varint,7,opt,name=changed" json:"changed,omitempty"`
+	// True while the entity's player has lost their connection, and lingers waiting for them to reconnect
+	LinkDead bool `protobuf:"varint,8,opt,name=link_dead,json=linkDead" json:"link_dead,omitempty"`
+}
+
+func (m *EntityState) Reset()                    { *m = EntityState{} }
+func (m *EntityState) String() string            { return proto.CompactTextString(m) }
+func (*EntityState) ProtoMessage()               {}
+func (*EntityState) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *EntityState) GetId() uint32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *EntityState) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *EntityState) GetX() float64 {
+	if m != nil {
+		return m.X
+	}
+	return 0
+}
+
+func (m *EntityState) GetY() float64 {
+	if m != nil {
+		return m.Y
+	}
+	return 0
+}
+
+func (m *EntityState) GetOrientation() float64 {
+	if m != nil {
+		return m.Orientation
+	}
+	return 0
+}
+
+func (m *EntityState) GetHealth() int32 {
+	if m != nil {
+		return m.Health
+	}
+	return 0
+}
+
+func (m *EntityState) GetChanged() uint32 {
+	if m != nil {
+		return m.Changed
+	}
+	return 0
+}
+
+func (m *EntityState) GetLinkDead() bool {
+	if m != nil {
+		return m.LinkDead
+	}
+	return false
+}
+
+// BlockData is a block of map cells, sent by the server
+type BlockData struct {
+	// Position of the block's first cell, in map space coordinates
+	X int32 `protobuf:"varint,1,opt,name=x" json:"x,omitempty"`
+	Y int32 `protobuf:"varint,2,opt,name=y" json:"y,omitempty"`
+	// Size of the block, in cells
+	Width  int32 `protobuf:"varint,3,opt,name=width" json:"width,omitempty"`
+	Height int32 `protobuf:"varint,4,opt,name=height" json:"height,omitempty"`
+	// Biome of each cell in the block, column by column (index = x * height + y)
+	Biomes []int32 `protobuf:"varint,5,rep,packed,name=biomes" json:"biomes,omitempty"`
+	// Shape of each cell in the block, in the same order as biomes
+	Shapes []int32 `protobuf:"varint,6,rep,packed,name=shapes" json:"shapes,omitempty"`
+}
+
+func (m *BlockData) Reset()                    { *m = BlockData{} }
+func (m *BlockData) String() string            { return proto.CompactTextString(m) }
+func (*BlockData) ProtoMessage()               {}
+func (*BlockData) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *BlockData) GetX() int32 {
+	if m != nil {
+		return m.X
+	}
+	return 0
+}
+
+func (m *BlockData) GetY() int32 {
+	if m != nil {
+		return m.Y
+	}
+	return 0
+}
+
+func (m *BlockData) GetWidth() int32 {
+	if m != nil {
+		return m.Width
+	}
+	return 0
+}
+
+func (m *BlockData) GetHeight() int32 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *BlockData) GetBiomes() []int32 {
+	if m != nil {
+		return m.Biomes
+	}
+	return nil
+}
+
+func (m *BlockData) GetShapes() []int32 {
+	if m != nil {
+		return m.Shapes
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Message)(nil), "protobuf.Message")
+	proto.RegisterType((*Hello)(nil), "protobuf.Hello")
+	proto.RegisterType((*Welcome)(nil), "protobuf.Welcome")
+	proto.RegisterType((*Reject)(nil), "protobuf.Reject")
+	proto.RegisterType((*Error)(nil), "protobuf.Error")
 	proto.RegisterType((*Move)(nil), "protobuf.Move")
 	proto.RegisterType((*Attack)(nil), "protobuf.Attack")
 	proto.RegisterType((*Build)(nil), "protobuf.Build")
 	proto.RegisterType((*Sleep)(nil), "protobuf.Sleep")
-	proto.RegisterEnum("protobuf.Message_Type", Message_Type_name, Message_Type_value)
+	proto.RegisterType((*Spectate)(nil), "protobuf.Spectate")
+	proto.RegisterType((*ChatSend)(nil), "protobuf.ChatSend")
+	proto.RegisterType((*ChatReceive)(nil), "protobuf.ChatReceive")
+	proto.RegisterType((*AdminCommand)(nil), "protobuf.AdminCommand")
+	proto.RegisterType((*AdminResult)(nil), "protobuf.AdminResult")
+	proto.RegisterType((*SnapshotAck)(nil), "protobuf.SnapshotAck")
+	proto.RegisterType((*Snapshot)(nil), "protobuf.Snapshot")
+	proto.RegisterType((*EntityState)(nil), "protobuf.EntityState")
+	proto.RegisterType((*BlockData)(nil), "protobuf.BlockData")
+	proto.RegisterEnum("protobuf.ChatChannel", ChatChannel_name, ChatChannel_value)
+	proto.RegisterEnum("protobuf.Error_Code", Error_Code_name, Error_Code_value)
 }
 
 func init() { proto.RegisterFile("message.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 312 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x90, 0x41, 0x4f, 0xfa, 0x40,
-	0x10, 0xc5, 0x5b, 0xfe, 0xdb, 0x42, 0x87, 0x3f, 0xb8, 0x99, 0x03, 0x69, 0xd4, 0x03, 0x59, 0x49,
-	0x24, 0x1c, 0x7a, 0xc0, 0xab, 0x17, 0x50, 0x12, 0x8c, 0x20, 0xa4, 0xa0, 0xf7, 0x85, 0xae, 0x84,
-	0x08, 0x6e, 0x53, 0xb6, 0x24, 0xfd, 0x3a, 0x7e, 0x52, 0xb3, 0xdb, 0x6a, 0x1b, 0x4f, 0x3b, 0xf3,
-	0xde, 0x2f, 0xd9, 0x79, 0x0f, 0x5a, 0x47, 0x71, 0x3a, 0xf1, 0x9d, 0x08, 0xe2, 0x44, 0x2a, 0x89,
-	0x0d, 0xf3, 0x6c, 0xd2, 0x77, 0xf6, 0x55, 0x83, 0xfa, 0x3c, 0xf7, 0x70, 0x00, 0x44, 0x65, 0xb1,
-	0xf0, 0xed, 0xae, 0xdd, 0x6f, 0x0f, 0x3b, 0xc1, 0x0f, 0x14, 0x14, 0x40, 0xb0, 0xce, 0x62, 0x11,
-	0x1a, 0x06, 0x7b, 0x40, 0x8e, 0xf2, 0x2c, 0x7c, 0xe8, 0xda, 0xfd, 0xe6, 0xb0, 0x5d, 0x61, 0xe5,
-	0x59, 0x4c, 0xad, 0xd0, 0xb8, 0x38, 0x00, 0x97, 0x2b, 0xc5, 0xb7, 0x1f, 0x7e, 0xd3, 0x70, 0xb4,
-	0xe4, 0x46, 0x46, 0x9f, 0x5a, 0x61, 0x41, 0xe0, 0x2d, 0x38, 0x9b, 0x74, 0x7f, 0x88, 0xfc, 0xff,
-	0x06, 0xbd, 0x28, 0xd1, 0xb1, 0x96, 0xa7, 0x56, 0x98, 0xfb, 0x1a, 0x3c, 0x1d, 0x84, 0x88, 0xfd,
-	0xd6, 0x5f, 0x70, 0xa5, 0x65, 0x0d, 0x1a, 0x9f, 0xdd, 0x03, 0xd1, 0x17, 0x63, 0x03, 0xc8, 0xcb,
-	0x62, 0xb1, 0xa4, 0x96, 0x9e, 0xe6, 0x8b, 0xb7, 0x09, 0xb5, 0x11, 0xc0, 0x1d, 0xad, 0xd7, 0xa3,
-	0x87, 0x67, 0x5a, 0x43, 0x0f, 0x9c, 0xf1, 0xeb, 0xd3, 0xec, 0x91, 0xfe, 0xd3, 0xe3, 0x6a, 0x36,
-	0x99, 0x2c, 0x29, 0x19, 0x7b, 0x50, 0x8f, 0x79, 0x76, 0x90, 0x3c, 0x62, 0x3d, 0x20, 0x3a, 0x16,
-	0x5e, 0x83, 0x17, 0xed, 0x13, 0xb1, 0x55, 0x7b, 0xf9, 0x69, 0x5a, 0xf2, 0xc2, 0x52, 0x60, 0x5d,
-	0x70, 0xf3, 0x50, 0xd8, 0x01, 0x57, 0xf1, 0x64, 0x27, 0x54, 0x01, 0x15, 0x1b, 0xbb, 0x02, 0xc7,
-	0x64, 0x41, 0xac, 0x34, 0xed, 0xe5, 0x8d, 0xb2, 0x1b, 0x70, 0xcc, 0xfd, 0x78, 0x09, 0x8d, 0x28,
-	0x4d, 0x78, 0xe5, 0x93, 0xdf, 0x7d, 0xe3, 0x9a, 0xac, 0x77, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff,
-	0x5e, 0x0c, 0xa2, 0x9e, 0xd0, 0x01, 0x00, 0x00,
+	// 1401 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0xdd, 0x6e, 0xdb, 0xc6,
+	0x12, 0x16, 0x25, 0x51, 0xa2, 0x46, 0x92, 0xcd, 0xec, 0x71, 0x1c, 0x9e, 0xe4, 0xfc, 0x28, 0x3a,
+	0x07, 0x38, 0x3a, 0x01, 0xea, 0x36, 0x69, 0x81, 0x02, 0x45, 0xd3, 0x56, 0xb6, 0x98, 0x48, 0xb0,
+	0x2c, 0xa5, 0x2b, 0x3b, 0xbe, 0x24, 0xd6, 0xe4, 0xda, 0x62, 0x45, 0x91, 0x2a, 0x49, 0x3b, 0xf1,
+	0x03, 0x14, 0xbd, 0xec, 0x13, 0xf4, 0xb2, 0x4f, 0xd0, 0xfb, 0xbe, 0x52, 0x1f, 0xa1, 0xc5, 0xcc,
+	0x92, 0x12, 0xa3, 0xa4, 0x01, 0x0a, 0xf4, 0x4a, 0xfb, 0xcd, 0xf7, 0xcd, 0xee, 0x70, 0x67, 0x76,
+	0x46, 0xd0, 0x5e, 0xca, 0x24, 0x11, 0x57, 0xf2, 0x60, 0x15, 0x47, 0x69, 0xc4, 0x0c, 0xfa, 0xb9,
+	0xb8, 0xbe, 0xec, 0xfe, 0x54, 0x83, 0xfa, 0x89, 0xe2, 0xd8, 0x7f, 0xa1, 0xba, 0x8c, 0x6e, 0xa4,
+	0x05, 0x1d, 0xad, 0xd7, 0x7c, 0xb2, 0x73, 0x90, 0x8b, 0x0e, 0x4e, 0xa2, 0x1b, 0x39, 0x2c, 0x71,
+	0x62, 0xd9, 0x23, 0xa8, 0x89, 0x34, 0x15, 0xee, 0xc2, 0x6a, 0x92, 0xce, 0xdc, 0xe8, 0xfa, 0x64,
+	0x1f, 0x96, 0x78, 0xa6, 0x60, 0xff, 0x03, 0xfd, 0xe2, 0xda, 0x0f, 0x3c, 0xab, 0x45, 0xd2, 0xdd,
+	0x8d, 0xf4, 0x10, 0xcd, 0xc3, 0x12, 0x57, 0x3c, 0x0a, 0x93, 0x40, 0xca, 0x95, 0xd5, 0xde, 0x16,
+	0xce, 0xd0, 0x8c, 0x42, 0xe2, 0xd9, 0x67, 0xd0, 0x4a, 0x42, 0xb1, 0x4a, 0xe6, 0x51, 0xea, 0x60,
+	0x0c, 0x3b, 0xa4, 0xbf, 0x5b, 0xd0, 0x67, 0x6c, 0x9f, 0x02, 0x69, 0x26, 0x1b, 0x88, 0x87, 0xcc,
+	0x65, 0x10, 0x44, 0xd6, 0xee, 0xf6, 0x21, 0x43, 0x34, 0xe3, 0x21, 0xc4, 0xb3, 0x8f, 0xc0, 0x48,
+	0x56, 0xd2, 0x4d, 0x45, 0x2a, 0x2d, 0x93, 0xb4, 0xac, 0x70, 0x40, 0xc6, 0x0c, 0x4b, 0x7c, 0xad,
+	0x62, 0x8f, 0xa1, 0xe1, 0xce, 0x45, 0xea, 0x24, 0x32, 0xf4, 0xac, 0x3b, 0xdb, 0x2e, 0x47, 0x73,
+	0x91, 0xce, 0x64, 0x88, 0xdf, 0x6b, 0xb8, 0xd9, 0x9a, 0x3d, 0x85, 0xb6, 0xf0, 0x96, 0x7e, 0xe8,
+	0xb8, 0xd1, 0x72, 0x29, 0x42, 0xcf, 0x62, 0xe4, 0xb6, 0x5f, 0xb8, 0x4e, 0xa4, 0x8f, 0x14, 0x3b,
+	0x2c, 0xf1, 0x96, 0x28, 0x60, 0x8a, 0x31, 0xfb, 0x36, 0x6b, 0xef, 0xad, 0x18, 0x33, 0x86, 0x62,
+	0xcc, 0xd6, 0xec, 0x13, 0x80, 0x8b, 0x20, 0x72, 0x17, 0x8e, 0x27, 0x52, 0x61, 0xdd, 0x25, 0x9f,
+	0xbf, 0x15, 0x32, 0x82, 0xdc, 0x40, 0xa4, 0x62, 0x58, 0xe2, 0x8d, 0x8b, 0x1c, 0xb0, 0x0f, 0xa0,
+	0xfe, 0x4a, 0x06, 0x6e, 0xb4, 0x94, 0xd6, 0x3e, 0xb9, 0xdc, 0xd9, 0xb8, 0x9c, 0x2b, 0x62, 0x58,
+	0xe2, 0xb9, 0x06, 0xab, 0x23, 0x96, 0xdf, 0x48, 0x37, 0xb5, 0xee, 0x6d, 0x57, 0x07, 0x27, 0x3b,
+	0x56, 0x87, 0x52, 0x60, 0x3e, 0x64, 0x1c, 0x47, 0xb1, 0x65, 0x6d, 0xe7, 0xc3, 0x46, 0x33, 0xe6,
+	0x83, 0x78, 0x4c, 0x3a, 0xdd, 0x6e, 0x2c, 0x5d, 0xe9, 0xdf, 0x48, 0xeb, 0xef, 0xdb, 0x49, 0xc7,
+	0x0b, 0xe6, 0x8a, 0xc4, 0xa4, 0xbb, 0x1b, 0x88, 0xbe, 0xea, 0x9a, 0x63, 0x99, 0x5c, 0x07, 0xa9,
+	0x75, 0x7f, 0xdb, 0x97, 0x6e, 0x99, 0x13, 0x89, 0xbe, 0x62, 0x03, 0x0f, 0x1b, 0x50, 0x5f, 0x89,
+	0xdb, 0x20, 0x12, 0x5e, 0xf7, 0x7b, 0x0d, 0x74, 0xaa, 0x12, 0xf6, 0x7f, 0x30, 0xc9, 0xd7, 0x8d,
+	0x02, 0xe7, 0x46, 0xc6, 0x89, 0x1f, 0x85, 0x96, 0xd6, 0xd1, 0x7a, 0x6d, 0xbe, 0x9b, 0xdb, 0x5f,
+	0x2a, 0x33, 0xdb, 0x03, 0x3d, 0x8d, 0x16, 0x32, 0xb4, 0xca, 0x1d, 0xad, 0xd7, 0xe0, 0x0a, 0xb0,
+	0x87, 0xd0, 0xc2, 0x58, 0x96, 0xd2, 0x51, 0x64, 0x85, 0xc8, 0xa6, 0xb2, 0x9d, 0x92, 0xe4, 0x7e,
+	0xa1, 0x00, 0xab, 0x1d, 0xad, 0x67, 0x6c, 0x4a, 0xad, 0xfb, 0x63, 0x05, 0xea, 0xd9, 0xc5, 0xff,
+	0x99, 0x58, 0x1e, 0x40, 0x43, 0x86, 0xa9, 0x9f, 0xde, 0x3a, 0xbe, 0x47, 0xf1, 0xb4, 0xb9, 0xa1,
+	0x0c, 0x23, 0x8f, 0xfd, 0x1b, 0x9a, 0xab, 0x40, 0xdc, 0xca, 0xd8, 0x09, 0xc5, 0x52, 0x66, 0x11,
+	0x81, 0x32, 0x4d, 0xc4, 0x52, 0xa2, 0xf7, 0x52, 0xac, 0x9c, 0x57, 0xbe, 0x97, 0xce, 0x29, 0x22,
+	0x9d, 0x1b, 0x4b, 0xb1, 0x3a, 0x47, 0xcc, 0xfe, 0x09, 0x80, 0xe4, 0x5c, 0xfa, 0x57, 0xf3, 0xd4,
+	0xd2, 0x89, 0x45, 0xf9, 0x90, 0x0c, 0xb8, 0xb9, 0xaa, 0x3b, 0xe5, 0x5d, 0x23, 0x5e, 0x95, 0xa2,
+	0xf2, 0x7f, 0x08, 0x2d, 0x25, 0xc8, 0x76, 0xa8, 0x93, 0x42, 0x39, 0x65, 0x7b, 0x30, 0xa8, 0x26,
+	0x52, 0x7a, 0x96, 0xd1, 0xd1, 0x7a, 0x15, 0x4e, 0x6b, 0x8c, 0x29, 0xf5, 0xdd, 0x85, 0x13, 0xe3,
+	0x2d, 0x35, 0x54, 0x4c, 0x68, 0xe0, 0xf8, 0x20, 0x1f, 0x40, 0x03, 0xdf, 0xa2, 0x22, 0x41, 0x91,
+	0x68, 0x20, 0x72, 0x3b, 0x03, 0xcd, 0xb7, 0x33, 0x60, 0x41, 0x5d, 0x41, 0xd5, 0xbb, 0x0c, 0x9e,
+	0x43, 0xf6, 0x2f, 0x80, 0x2c, 0x17, 0x7e, 0x78, 0x45, 0xfd, 0xca, 0xe0, 0x05, 0x4b, 0xf7, 0x18,
+	0x6a, 0xaa, 0xd2, 0xd9, 0x3e, 0xbe, 0x05, 0x91, 0x64, 0x39, 0x69, 0xf0, 0x0c, 0xbd, 0x33, 0x6b,
+	0xe5, 0x77, 0x66, 0xad, 0xfb, 0x9b, 0x06, 0x3a, 0x3d, 0x06, 0xd6, 0x83, 0xaa, 0x1b, 0x79, 0x92,
+	0xb6, 0xda, 0x79, 0xb2, 0xb7, 0xf5, 0x56, 0x0e, 0x8e, 0x22, 0x4f, 0x72, 0x52, 0x14, 0x8e, 0x2d,
+	0x17, 0x8f, 0xed, 0xfe, 0xac, 0x41, 0x15, 0x65, 0xac, 0x09, 0xf5, 0xb3, 0xc9, 0xf1, 0x64, 0x7a,
+	0x3e, 0x31, 0x4b, 0xac, 0x0d, 0x8d, 0x93, 0xfe, 0xf8, 0xd9, 0x94, 0x9f, 0xd8, 0x03, 0x53, 0x63,
+	0xbb, 0xd0, 0x3c, 0x9b, 0xcc, 0xce, 0x5e, 0xbc, 0x98, 0xf2, 0x53, 0x7b, 0x60, 0x96, 0xd9, 0x3e,
+	0xb0, 0x61, 0x7f, 0x32, 0x98, 0x0d, 0xfb, 0xc7, 0xb6, 0xc3, 0xed, 0xaf, 0xcf, 0x46, 0xdc, 0x1e,
+	0x98, 0x15, 0x66, 0x42, 0x8b, 0xf7, 0x4f, 0x6d, 0x67, 0x3c, 0x3a, 0x19, 0xa1, 0xb2, 0x8a, 0x3b,
+	0x9d, 0x4e, 0xa7, 0xce, 0xb8, 0xcf, 0x9f, 0xdb, 0xa6, 0xce, 0x76, 0x00, 0xce, 0xf9, 0x74, 0xf2,
+	0xdc, 0x39, 0x99, 0x0e, 0x6c, 0xb3, 0x86, 0xf4, 0x64, 0x7a, 0xea, 0x3c, 0x9b, 0x9e, 0x4d, 0x06,
+	0x66, 0x1d, 0x83, 0x18, 0x4d, 0x5e, 0xf6, 0xc7, 0xa3, 0x81, 0x69, 0x20, 0xf7, 0x6c, 0xca, 0x0f,
+	0x47, 0x83, 0x81, 0x3d, 0x31, 0x1b, 0x0c, 0xa0, 0x76, 0x3c, 0x3a, 0x3a, 0xb6, 0x07, 0x26, 0x74,
+	0xbf, 0x82, 0x2a, 0x8e, 0x1f, 0xf6, 0x0f, 0x68, 0x78, 0x7e, 0x2c, 0xdd, 0xd4, 0x5f, 0xdf, 0xe7,
+	0xc6, 0x40, 0x0f, 0x46, 0x7e, 0x7b, 0x2d, 0x43, 0x57, 0xe6, 0xc5, 0x9d, 0xe3, 0xee, 0xe7, 0x50,
+	0x53, 0x83, 0x09, 0x6f, 0x26, 0x15, 0xf1, 0x95, 0x4c, 0xf3, 0x84, 0x28, 0xf4, 0x5e, 0xef, 0x4f,
+	0x41, 0xa7, 0x59, 0x85, 0x25, 0x98, 0xde, 0xae, 0x64, 0xe6, 0x4a, 0xeb, 0xf7, 0x3a, 0xfe, 0x07,
+	0x74, 0x9a, 0x5d, 0x28, 0xf2, 0xae, 0x63, 0x51, 0x08, 0x7c, 0x8d, 0xbb, 0x5f, 0x80, 0x91, 0xcf,
+	0x13, 0xd6, 0x02, 0xed, 0x35, 0x09, 0x34, 0xae, 0xbd, 0x46, 0x74, 0x4b, 0x7b, 0x6a, 0x5c, 0xbb,
+	0xc5, 0xc8, 0x2f, 0xa3, 0x20, 0x88, 0x5e, 0x65, 0x6f, 0x33, 0x43, 0x5d, 0x07, 0x8c, 0x7c, 0xb8,
+	0xb0, 0x0f, 0xa1, 0xee, 0xce, 0x45, 0x18, 0xca, 0x20, 0x2b, 0x92, 0xad, 0x06, 0x79, 0xa4, 0x48,
+	0x9e, 0xab, 0xe8, 0x8b, 0xe4, 0xeb, 0x34, 0x2b, 0x13, 0x5a, 0xb3, 0x1d, 0x28, 0xa7, 0x51, 0x76,
+	0x48, 0x39, 0x8d, 0xba, 0x3f, 0x68, 0xd0, 0x2c, 0x74, 0xd7, 0xbf, 0xe6, 0x10, 0x06, 0xd5, 0xcb,
+	0x38, 0x5a, 0x66, 0xc7, 0xd0, 0x9a, 0xdd, 0x83, 0x3a, 0xfe, 0x62, 0x77, 0xaa, 0xd2, 0x4d, 0xd6,
+	0x10, 0x8e, 0xbc, 0x2c, 0x22, 0x7d, 0x1d, 0x51, 0x0f, 0x5a, 0xc5, 0xc1, 0x88, 0x2f, 0x35, 0x9f,
+	0xa0, 0xea, 0x76, 0x73, 0xd8, 0xfd, 0x12, 0x9a, 0x85, 0xe6, 0xfe, 0xc7, 0x42, 0xbc, 0xdd, 0xe8,
+	0x3a, 0x5d, 0x5d, 0xe7, 0x51, 0x66, 0xa8, 0xfb, 0x10, 0x9a, 0x85, 0xbf, 0x13, 0xf4, 0x29, 0xbe,
+	0xbb, 0xc8, 0x3a, 0x2c, 0xad, 0xbb, 0xbf, 0x6a, 0x60, 0xe4, 0x9a, 0x77, 0x09, 0xd8, 0x63, 0x50,
+	0x6d, 0xd6, 0x97, 0x89, 0x55, 0xee, 0x54, 0xde, 0x9c, 0x3d, 0x36, 0x35, 0xe0, 0x19, 0xa6, 0x9f,
+	0xaf, 0x65, 0x38, 0x36, 0x3c, 0x19, 0xa4, 0x82, 0xee, 0xc7, 0xe0, 0x0a, 0x60, 0x47, 0xbb, 0x10,
+	0x89, 0x74, 0xe8, 0x04, 0x75, 0x45, 0x06, 0x1a, 0x4e, 0xf1, 0x14, 0x6a, 0x57, 0xf8, 0xf7, 0xcc,
+	0xb3, 0xf4, 0x4e, 0xa5, 0xd7, 0xe6, 0x39, 0x44, 0x46, 0x86, 0xa9, 0x8c, 0xa5, 0x67, 0xd5, 0x14,
+	0x93, 0x41, 0x8c, 0x36, 0x90, 0x97, 0xd8, 0x6e, 0xd1, 0x4c, 0x6b, 0xec, 0x8c, 0xc2, 0x5d, 0x38,
+	0xeb, 0xa2, 0x36, 0xe8, 0x9c, 0xa6, 0x70, 0x17, 0xb3, 0xbc, 0xae, 0x7f, 0xd1, 0xa0, 0x59, 0x88,
+	0x1b, 0xf3, 0xe3, 0x7b, 0xd9, 0x27, 0x97, 0x7d, 0xda, 0x96, 0x86, 0x48, 0x96, 0x70, 0x5c, 0xab,
+	0xd2, 0xae, 0xbc, 0x51, 0xda, 0xd5, 0xbc, 0xb4, 0x3b, 0xd0, 0x8c, 0x62, 0x5f, 0x86, 0xa9, 0x7a,
+	0x21, 0x3a, 0xd9, 0x8b, 0x26, 0x4c, 0xcf, 0x5c, 0x8a, 0x60, 0x3d, 0x3b, 0x32, 0x44, 0x09, 0x9d,
+	0x8b, 0xf0, 0x4a, 0x7a, 0x34, 0x32, 0xda, 0x3c, 0x87, 0x78, 0x57, 0x81, 0x1f, 0x2e, 0x1c, 0x4f,
+	0x0a, 0x35, 0x33, 0x0c, 0x6e, 0xa0, 0x61, 0x20, 0x85, 0xd7, 0xfd, 0x4e, 0x83, 0xc6, 0xfa, 0xcf,
+	0xce, 0xe6, 0xd5, 0xe9, 0x6f, 0xbc, 0x3a, 0x1d, 0x43, 0xdb, 0x03, 0x5d, 0xcd, 0xac, 0x0a, 0x59,
+	0x14, 0x50, 0xe1, 0xd0, 0xa0, 0xaa, 0xe6, 0xe1, 0x20, 0x42, 0xfb, 0x85, 0x1f, 0x2d, 0x65, 0x42,
+	0x29, 0xd0, 0x79, 0x86, 0xd0, 0x9e, 0xcc, 0xc5, 0x4a, 0x26, 0x94, 0x00, 0x9d, 0x67, 0xe8, 0xd1,
+	0x53, 0x68, 0x16, 0x5e, 0x0c, 0x36, 0xbd, 0xe7, 0xe3, 0xe9, 0x61, 0x7f, 0x6c, 0x96, 0x58, 0x03,
+	0xf4, 0xf1, 0xf4, 0xa8, 0x3f, 0x36, 0x35, 0xec, 0x93, 0xe7, 0xc3, 0xd1, 0xec, 0x85, 0xcd, 0xcd,
+	0x32, 0x6a, 0x66, 0x36, 0x7f, 0x69, 0x73, 0xb3, 0x72, 0x51, 0xa3, 0x2a, 0xfa, 0xf8, 0xf7, 0x01,
+	0x00, 0x2d, 0xc1, 0x54, 0x72, 0xdb, 0x0b, 0x00, 0x00,
 }
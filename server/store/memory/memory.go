@@ -0,0 +1,221 @@
+// Package memory implements the store interfaces in memory, for tests and local runs.
+// Everything stored is lost when the server stops.
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/store"
+	"bitbucket.org/ehhio/ehhworldserver/server/utility"
+)
+
+// Store keeps all persistent game data in maps
+type Store struct {
+	mutex           sync.Mutex
+	accounts        map[string]*store.Account
+	characters      map[int]*store.Character
+	positions       map[int]*utility.PositionHighResolution
+	inventories     map[int][]*store.InventorySlot
+	minimaps        map[int]*utility.BooleanMatrix
+	lastAccountID   int
+	lastCharacterID int
+}
+
+// Verify that *Store implements store.Store
+var _ store.Store = (*Store)(nil)
+
+// NewStore creates an empty in-memory store
+func NewStore() *Store {
+	return &Store{
+		accounts:    make(map[string]*store.Account),
+		characters:  make(map[int]*store.Character),
+		positions:   make(map[int]*utility.PositionHighResolution),
+		inventories: make(map[int][]*store.InventorySlot),
+		minimaps:    make(map[int]*utility.BooleanMatrix),
+	}
+}
+
+// Close does nothing, as there is nothing to release
+func (s *Store) Close() error {
+	return nil
+}
+
+// CreateAccount stores a new account, returning store.ErrConflict if the name is in use
+func (s *Store) CreateAccount(name string, passwordHash []byte) (*store.Account, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.accounts[name]; exists {
+		return nil, store.ErrConflict
+	}
+
+	s.lastAccountID++
+	account := &store.Account{
+		ID:           s.lastAccountID,
+		Name:         name,
+		PasswordHash: append([]byte(nil), passwordHash...),
+		Created:      time.Now().UTC(),
+	}
+	s.accounts[name] = account
+
+	copied := *account
+	return &copied, nil
+}
+
+// GetAccountByName returns the account with a name, or store.ErrNotFound
+func (s *Store) GetAccountByName(name string) (*store.Account, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	account, exists := s.accounts[name]
+	if !exists {
+		return nil, store.ErrNotFound
+	}
+
+	copied := *account
+	return &copied, nil
+}
+
+// CreateCharacter stores a new character for an account, returning store.ErrConflict if the name is in use
+func (s *Store) CreateCharacter(accountID int, name string, health int32) (*store.Character, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, character := range s.characters {
+		if character.Name == name {
+			return nil, store.ErrConflict
+		}
+	}
+
+	s.lastCharacterID++
+	character := &store.Character{
+		ID:        s.lastCharacterID,
+		AccountID: accountID,
+		Name:      name,
+		Health:    health,
+		Created:   time.Now().UTC(),
+	}
+	s.characters[character.ID] = character
+
+	copied := *character
+	return &copied, nil
+}
+
+// GetCharacterByName returns the character with a name, or store.ErrNotFound
+func (s *Store) GetCharacterByName(name string) (*store.Character, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, character := range s.characters {
+		if character.Name == name {
+			copied := *character
+			return &copied, nil
+		}
+	}
+
+	return nil, store.ErrNotFound
+}
+
+// GetCharactersByAccount returns all characters belonging to an account, in the order they were created
+func (s *Store) GetCharactersByAccount(accountID int) ([]*store.Character, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	characters := make([]*store.Character, 0)
+	for _, character := range s.characters {
+		if character.AccountID == accountID {
+			copied := *character
+			characters = append(characters, &copied)
+		}
+	}
+	sort.Slice(characters, func(i, j int) bool { return characters[i].ID < characters[j].ID })
+
+	return characters, nil
+}
+
+// UpdateCharacter saves the changeable state of a character, returning store.ErrNotFound if it doesn't exist
+func (s *Store) UpdateCharacter(character *store.Character) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, exists := s.characters[character.ID]
+	if !exists {
+		return store.ErrNotFound
+	}
+
+	stored.Health = character.Health
+	return nil
+}
+
+// SavePosition stores the position of a character, in map space coordinates
+func (s *Store) SavePosition(characterID int, position *utility.PositionHighResolution) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.positions[characterID] = &utility.PositionHighResolution{X: position.X, Y: position.Y}
+	return nil
+}
+
+// LoadPosition returns the stored position of a character, or store.ErrNotFound
+func (s *Store) LoadPosition(characterID int) (*utility.PositionHighResolution, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	position, exists := s.positions[characterID]
+	if !exists {
+		return nil, store.ErrNotFound
+	}
+
+	return &utility.PositionHighResolution{X: position.X, Y: position.Y}, nil
+}
+
+// SaveInventory replaces the stored inventory of a character
+func (s *Store) SaveInventory(characterID int, slots []*store.InventorySlot) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.inventories[characterID] = copySlots(slots)
+	return nil
+}
+
+// LoadInventory returns the stored inventory of a character, which is empty if none was saved
+func (s *Store) LoadInventory(characterID int) ([]*store.InventorySlot, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return copySlots(s.inventories[characterID]), nil
+}
+
+// SaveKnownBlocks stores the matrix of map blocks a character knows about
+func (s *Store) SaveKnownBlocks(characterID int, known *utility.BooleanMatrix) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.minimaps[characterID] = known.Copy()
+	return nil
+}
+
+// LoadKnownBlocks returns the stored matrix of map blocks a character knows about, or store.ErrNotFound
+func (s *Store) LoadKnownBlocks(characterID int) (*utility.BooleanMatrix, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	known, exists := s.minimaps[characterID]
+	if !exists {
+		return nil, store.ErrNotFound
+	}
+
+	return known.Copy(), nil
+}
+
+// copySlots deep copies inventory slots, so callers can't change what is stored
+func copySlots(slots []*store.InventorySlot) []*store.InventorySlot {
+	copied := make([]*store.InventorySlot, 0, len(slots))
+	for _, slot := range slots {
+		copied = append(copied, &store.InventorySlot{ObjectID: slot.ObjectID, Count: slot.Count})
+	}
+
+	return copied
+}
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/store"
+	"bitbucket.org/ehhio/ehhworldserver/server/store/memory"
+	"bitbucket.org/ehhio/ehhworldserver/server/store/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		return memory.NewStore()
+	})
+}
@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Constants
+const (
+	// Columns every table starts with
+	baseColumns = `
+	-- base columns
+	id SERIAL PRIMARY KEY,
+	uuid UUID NOT NULL DEFAULT uuid_generate_v4(),
+	created timestamptz NOT NULL DEFAULT now(),
+	created_by text,
+	last_modified timestamptz,
+	last_modified_by text,
+	deleted timestamptz,
+	deleted_by text,`
+)
+
+// Migration is a versioned change to the database schema, and how to undo it
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is whether a migration has been applied to the database, and when
+type MigrationStatus struct {
+	Migration *Migration
+	Applied   *time.Time
+}
+
+// createTable returns a statement creating a table with the base columns followed by its own columns.
+// Tables that already exist, such as from the SQL init scripts older databases were set up with, are left as they are.
+func createTable(name, columns string) string {
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %v (%v\n\n\t-- table columns\n\t%v\n);", name, baseColumns, strings.TrimSpace(columns))
+}
+
+// ensureMigrationTable creates the table tracking applied migrations, if it doesn't exist yet
+func (s *Store) ensureMigrationTable() error {
+	if _, err := s.db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec(createTable("schema_migration", `
+	version INTEGER NOT NULL UNIQUE,
+	name TEXT NOT NULL`))
+
+	return err
+}
+
+// appliedMigrations returns when each applied migration was applied, by version.
+// Only reads the database; without a migration table, nothing has been applied.
+func (s *Store) appliedMigrations() (map[int]time.Time, error) {
+	var exists bool
+	if err := s.db.QueryRow(`SELECT to_regclass('schema_migration') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return map[int]time.Time{}, nil
+	}
+
+	rows, err := s.db.Query(`SELECT version, created FROM schema_migration WHERE deleted IS NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var created time.Time
+		if err := rows.Scan(&version, &created); err != nil {
+			return nil, err
+		}
+		applied[version] = created
+	}
+
+	return applied, rows.Err()
+}
+
+// MigrationStatus returns every known migration, in version order, and whether it has been applied
+func (s *Store) MigrationStatus() ([]*MigrationStatus, error) {
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := &MigrationStatus{Migration: migration}
+		if created, exists := applied[migration.Version]; exists {
+			status.Applied = &created
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// MigrateUp applies every migration that hasn't been applied yet, in version order.
+// Returns the migrations that were applied.
+func (s *Store) MigrateUp() ([]*Migration, error) {
+	if err := s.ensureMigrationTable(); err != nil {
+		return nil, err
+	}
+
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]*Migration, 0)
+	for _, migration := range migrations {
+		if _, exists := applied[migration.Version]; exists {
+			continue
+		}
+
+		if err := s.runMigration(migration.Up,
+			`INSERT INTO schema_migration (version, name, created_by) VALUES ($1, $2, $3)`,
+			migration.Version, migration.Name, modifiedBy); err != nil {
+			return done, fmt.Errorf("migration %v (%v) up: %v", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// MigrateDown undoes the most recent applied migration.
+// Returns the migration that was undone, or nil if none were applied.
+func (s *Store) MigrateDown() (*Migration, error) {
+	if err := s.ensureMigrationTable(); err != nil {
+		return nil, err
+	}
+
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		if _, exists := applied[migration.Version]; !exists {
+			continue
+		}
+
+		if err := s.runMigration(migration.Down,
+			`DELETE FROM schema_migration WHERE version = $1`,
+			migration.Version); err != nil {
+			return nil, fmt.Errorf("migration %v (%v) down: %v", migration.Version, migration.Name, err)
+		}
+		return migration, nil
+	}
+
+	return nil, nil
+}
+
+// runMigration runs a migration statement and records it, in a single transaction
+func (s *Store) runMigration(statement, record string, args ...interface{}) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(statement); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(record, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
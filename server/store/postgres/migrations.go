@@ -0,0 +1,70 @@
+package postgres
+
+// adoptPlayerAccount brings a player_account table created by the old SQL init scripts, which only had a name,
+// up to date. Accounts made before passwords existed get an empty password hash, so can't log in until it is set.
+// Altering the table needs its owner, which for those databases is the superuser that ran the init scripts.
+const adoptPlayerAccount = `
+ALTER TABLE player_account ADD COLUMN IF NOT EXISTS password_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE player_account ALTER COLUMN password_hash DROP DEFAULT;
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'player_account_name_key') THEN
+		ALTER TABLE player_account ADD CONSTRAINT player_account_name_key UNIQUE (name);
+	END IF;
+END
+$$;`
+
+// migrations is every change to the database schema, in the order they are applied.
+// Never edit a migration once it has been released; add a new one instead.
+var migrations = []*Migration{
+	{
+		Version: 1,
+		Name:    "create player_account",
+		Up: createTable("player_account", `
+	name TEXT NOT NULL UNIQUE,
+	password_hash TEXT NOT NULL`) + adoptPlayerAccount,
+		// The table may hold accounts from before migrations existed, so only what this migration added is undone
+		Down: `
+ALTER TABLE player_account DROP CONSTRAINT IF EXISTS player_account_name_key;
+ALTER TABLE player_account DROP COLUMN IF EXISTS password_hash;`,
+	},
+	{
+		Version: 2,
+		Name:    "create player_character",
+		Up: createTable("player_character", `
+	player_account_id INTEGER NOT NULL REFERENCES player_account (id),
+	name TEXT NOT NULL UNIQUE,
+	health INTEGER NOT NULL`),
+		Down: `DROP TABLE player_character;`,
+	},
+	{
+		Version: 3,
+		Name:    "create character_position",
+		Up: createTable("character_position", `
+	player_character_id INTEGER NOT NULL UNIQUE REFERENCES player_character (id),
+	x DOUBLE PRECISION NOT NULL,
+	y DOUBLE PRECISION NOT NULL`),
+		Down: `DROP TABLE character_position;`,
+	},
+	{
+		Version: 4,
+		Name:    "create character_inventory_slot",
+		Up: createTable("character_inventory_slot", `
+	player_character_id INTEGER NOT NULL REFERENCES player_character (id),
+	slot INTEGER NOT NULL,
+	object_id INTEGER NOT NULL,
+	count INTEGER NOT NULL,
+	UNIQUE (player_character_id, slot)`),
+		Down: `DROP TABLE character_inventory_slot;`,
+	},
+	{
+		Version: 5,
+		Name:    "create character_minimap",
+		Up: createTable("character_minimap", `
+	player_character_id INTEGER NOT NULL UNIQUE REFERENCES player_character (id),
+	width INTEGER NOT NULL,
+	height INTEGER NOT NULL,
+	known BYTEA NOT NULL`),
+		Down: `DROP TABLE character_minimap;`,
+	},
+}
@@ -0,0 +1,105 @@
+// Package store defines how persistent game data is saved and loaded.
+// Implementations live in sub-packages, so that users of the interfaces don't depend on any particular database.
+package store
+
+import (
+	"errors"
+	"time"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/utility"
+)
+
+// Errors
+var (
+	// ErrNotFound is returned when a requested record does not exist
+	ErrNotFound = errors.New("store: not found")
+
+	// ErrConflict is returned when a record would violate a uniqueness constraint, such as a taken name
+	ErrConflict = errors.New("store: conflict")
+)
+
+// Account is a registered player account
+type Account struct {
+	ID           int
+	Name         string
+	PasswordHash []byte
+	Created      time.Time
+}
+
+// Character is a player character belonging to an account
+type Character struct {
+	ID        int
+	AccountID int
+	Name      string
+	Health    int32
+	Created   time.Time
+}
+
+// InventorySlot is a stack of objects in a character's inventory
+type InventorySlot struct {
+	ObjectID int
+	Count    int
+}
+
+// AccountRepository saves and loads player accounts
+type AccountRepository interface {
+	// CreateAccount stores a new account, returning ErrConflict if the name is in use
+	CreateAccount(name string, passwordHash []byte) (*Account, error)
+
+	// GetAccountByName returns the account with a name, or ErrNotFound
+	GetAccountByName(name string) (*Account, error)
+}
+
+// CharacterRepository saves and loads player characters
+type CharacterRepository interface {
+	// CreateCharacter stores a new character for an account, returning ErrConflict if the name is in use
+	CreateCharacter(accountID int, name string, health int32) (*Character, error)
+
+	// GetCharacterByName returns the character with a name, or ErrNotFound
+	GetCharacterByName(name string) (*Character, error)
+
+	// GetCharactersByAccount returns all characters belonging to an account, in the order they were created
+	GetCharactersByAccount(accountID int) ([]*Character, error)
+
+	// UpdateCharacter saves the changeable state of a character, returning ErrNotFound if it doesn't exist
+	UpdateCharacter(character *Character) error
+}
+
+// PositionRepository saves and loads where characters are in the game map
+type PositionRepository interface {
+	// SavePosition stores the position of a character, in map space coordinates
+	SavePosition(characterID int, position *utility.PositionHighResolution) error
+
+	// LoadPosition returns the stored position of a character, or ErrNotFound
+	LoadPosition(characterID int) (*utility.PositionHighResolution, error)
+}
+
+// InventoryRepository saves and loads character inventories
+type InventoryRepository interface {
+	// SaveInventory replaces the stored inventory of a character
+	SaveInventory(characterID int, slots []*InventorySlot) error
+
+	// LoadInventory returns the stored inventory of a character, which is empty if none was saved
+	LoadInventory(characterID int) ([]*InventorySlot, error)
+}
+
+// MinimapRepository saves and loads which map blocks characters know about
+type MinimapRepository interface {
+	// SaveKnownBlocks stores the matrix of map blocks a character knows about
+	SaveKnownBlocks(characterID int, known *utility.BooleanMatrix) error
+
+	// LoadKnownBlocks returns the stored matrix of map blocks a character knows about, or ErrNotFound
+	LoadKnownBlocks(characterID int) (*utility.BooleanMatrix, error)
+}
+
+// Store is the complete set of repositories the server persists data with
+type Store interface {
+	AccountRepository
+	CharacterRepository
+	PositionRepository
+	InventoryRepository
+	MinimapRepository
+
+	// Close releases any resources held by the store
+	Close() error
+}
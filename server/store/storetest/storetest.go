@@ -0,0 +1,255 @@
+// Package storetest checks that implementations of the store interfaces behave the same,
+// so game code can't tell which one it is persisting to.
+package storetest
+
+import (
+	"bytes"
+	"testing"
+
+	"bitbucket.org/ehhio/ehhworldserver/server/store"
+	"bitbucket.org/ehhio/ehhworldserver/server/utility"
+)
+
+// storeTest is one behavior every store must have
+type storeTest struct {
+	name string
+	run  func(t *testing.T, s store.Store)
+}
+
+// storeTests are the behaviors checked by Run
+var storeTests = []storeTest{
+	{"AccountRoundTrip", testAccountRoundTrip},
+	{"AccountNameConflict", testAccountNameConflict},
+	{"AccountNotFound", testAccountNotFound},
+	{"CharacterRoundTrip", testCharacterRoundTrip},
+	{"CharacterNameConflict", testCharacterNameConflict},
+	{"CharacterNotFound", testCharacterNotFound},
+	{"CharactersByAccount", testCharactersByAccount},
+	{"UpdateCharacter", testUpdateCharacter},
+	{"Position", testPosition},
+	{"Inventory", testInventory},
+	{"KnownBlocks", testKnownBlocks},
+}
+
+// Run checks a store implementation against every behavior the store interfaces promise.
+// Open is called for each check, and must return an empty store.
+func Run(t *testing.T, open func(t *testing.T) store.Store) {
+	for _, test := range storeTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			s := open(t)
+			defer s.Close()
+
+			test.run(t, s)
+		})
+	}
+}
+
+// createCharacter stores an account with a character, failing the test if either can't be created
+func createCharacter(t *testing.T, s store.Store, name string) *store.Character {
+	t.Helper()
+
+	account, err := s.CreateAccount(name, []byte("hash of "+name))
+	if err != nil {
+		t.Fatalf("CreateAccount(%q) error = %v", name, err)
+	}
+	character, err := s.CreateCharacter(account.ID, name, 100)
+	if err != nil {
+		t.Fatalf("CreateCharacter(%q) error = %v", name, err)
+	}
+
+	return character
+}
+
+func testAccountRoundTrip(t *testing.T, s store.Store) {
+	created, err := s.CreateAccount("alice", []byte("hash"))
+	if err != nil {
+		t.Fatalf("CreateAccount() error = %v", err)
+	}
+	if created.ID == 0 || created.Created.IsZero() {
+		t.Errorf("CreateAccount() = %+v, want an ID and creation time", created)
+	}
+
+	loaded, err := s.GetAccountByName("alice")
+	if err != nil {
+		t.Fatalf("GetAccountByName() error = %v", err)
+	}
+	if loaded.ID != created.ID || loaded.Name != "alice" || !bytes.Equal(loaded.PasswordHash, []byte("hash")) {
+		t.Errorf("GetAccountByName() = %+v, want account %v named alice with hash \"hash\"", loaded, created.ID)
+	}
+}
+
+func testAccountNameConflict(t *testing.T, s store.Store) {
+	if _, err := s.CreateAccount("alice", []byte("hash")); err != nil {
+		t.Fatalf("CreateAccount() error = %v", err)
+	}
+	if _, err := s.CreateAccount("alice", []byte("other")); err != store.ErrConflict {
+		t.Errorf("CreateAccount() with a taken name error = %v, want %v", err, store.ErrConflict)
+	}
+}
+
+func testAccountNotFound(t *testing.T, s store.Store) {
+	if _, err := s.GetAccountByName("nobody"); err != store.ErrNotFound {
+		t.Errorf("GetAccountByName() error = %v, want %v", err, store.ErrNotFound)
+	}
+}
+
+func testCharacterRoundTrip(t *testing.T, s store.Store) {
+	created := createCharacter(t, s, "alice")
+
+	loaded, err := s.GetCharacterByName("alice")
+	if err != nil {
+		t.Fatalf("GetCharacterByName() error = %v", err)
+	}
+	if loaded.ID != created.ID || loaded.AccountID != created.AccountID || loaded.Health != 100 {
+		t.Errorf("GetCharacterByName() = %+v, want %+v", loaded, created)
+	}
+}
+
+func testCharacterNameConflict(t *testing.T, s store.Store) {
+	character := createCharacter(t, s, "alice")
+	if _, err := s.CreateCharacter(character.AccountID, "alice", 100); err != store.ErrConflict {
+		t.Errorf("CreateCharacter() with a taken name error = %v, want %v", err, store.ErrConflict)
+	}
+}
+
+func testCharacterNotFound(t *testing.T, s store.Store) {
+	if _, err := s.GetCharacterByName("nobody"); err != store.ErrNotFound {
+		t.Errorf("GetCharacterByName() error = %v, want %v", err, store.ErrNotFound)
+	}
+}
+
+func testCharactersByAccount(t *testing.T, s store.Store) {
+	first := createCharacter(t, s, "alice")
+	second, err := s.CreateCharacter(first.AccountID, "alice2", 50)
+	if err != nil {
+		t.Fatalf("CreateCharacter() error = %v", err)
+	}
+	createCharacter(t, s, "bob")
+
+	characters, err := s.GetCharactersByAccount(first.AccountID)
+	if err != nil {
+		t.Fatalf("GetCharactersByAccount() error = %v", err)
+	}
+	if len(characters) != 2 || characters[0].ID != first.ID || characters[1].ID != second.ID {
+		names := make([]string, 0, len(characters))
+		for _, character := range characters {
+			names = append(names, character.Name)
+		}
+		t.Errorf("GetCharactersByAccount() = %v, want [alice alice2]", names)
+	}
+
+	none, err := s.GetCharactersByAccount(first.AccountID + 1000)
+	if err != nil || len(none) != 0 {
+		t.Errorf("GetCharactersByAccount() of an unknown account = %v, %v, want none", none, err)
+	}
+}
+
+func testUpdateCharacter(t *testing.T, s store.Store) {
+	character := createCharacter(t, s, "alice")
+	character.Health = 42
+	if err := s.UpdateCharacter(character); err != nil {
+		t.Fatalf("UpdateCharacter() error = %v", err)
+	}
+
+	loaded, err := s.GetCharacterByName("alice")
+	if err != nil {
+		t.Fatalf("GetCharacterByName() error = %v", err)
+	}
+	if loaded.Health != 42 {
+		t.Errorf("health after UpdateCharacter() = %v, want 42", loaded.Health)
+	}
+
+	missing := &store.Character{ID: character.ID + 1000, Health: 1}
+	if err := s.UpdateCharacter(missing); err != store.ErrNotFound {
+		t.Errorf("UpdateCharacter() of an unknown character error = %v, want %v", err, store.ErrNotFound)
+	}
+}
+
+func testPosition(t *testing.T, s store.Store) {
+	character := createCharacter(t, s, "alice")
+	if _, err := s.LoadPosition(character.ID); err != store.ErrNotFound {
+		t.Errorf("LoadPosition() before saving error = %v, want %v", err, store.ErrNotFound)
+	}
+
+	positions := []*utility.PositionHighResolution{{X: 1.5, Y: 2.25}, {X: 63.999, Y: 0}}
+	for _, position := range positions {
+		if err := s.SavePosition(character.ID, position); err != nil {
+			t.Fatalf("SavePosition(%v) error = %v", position, err)
+		}
+
+		loaded, err := s.LoadPosition(character.ID)
+		if err != nil {
+			t.Fatalf("LoadPosition() error = %v", err)
+		}
+		if *loaded != *position {
+			t.Errorf("LoadPosition() = %v, want %v", loaded, position)
+		}
+	}
+}
+
+func testInventory(t *testing.T, s store.Store) {
+	character := createCharacter(t, s, "alice")
+	if slots, err := s.LoadInventory(character.ID); err != nil || len(slots) != 0 {
+		t.Errorf("LoadInventory() before saving = %v, %v, want empty", slots, err)
+	}
+
+	inventories := [][]*store.InventorySlot{
+		{{ObjectID: 1, Count: 5}, {ObjectID: 7, Count: 1}, {ObjectID: 3, Count: 20}},
+		{{ObjectID: 9, Count: 2}},
+		{},
+	}
+	for _, inventory := range inventories {
+		if err := s.SaveInventory(character.ID, inventory); err != nil {
+			t.Fatalf("SaveInventory() error = %v", err)
+		}
+
+		loaded, err := s.LoadInventory(character.ID)
+		if err != nil {
+			t.Fatalf("LoadInventory() error = %v", err)
+		}
+		if len(loaded) != len(inventory) {
+			t.Fatalf("LoadInventory() = %v slots, want %v, replacing what was saved before", len(loaded), len(inventory))
+		}
+		for i := range inventory {
+			if *loaded[i] != *inventory[i] {
+				t.Errorf("LoadInventory() slot %v = %+v, want %+v", i, loaded[i], inventory[i])
+			}
+		}
+	}
+}
+
+func testKnownBlocks(t *testing.T, s store.Store) {
+	character := createCharacter(t, s, "alice")
+	if _, err := s.LoadKnownBlocks(character.ID); err != store.ErrNotFound {
+		t.Errorf("LoadKnownBlocks() before saving error = %v, want %v", err, store.ErrNotFound)
+	}
+
+	// Sizes that don't fill whole bytes, and a resize, to catch packing mistakes
+	for _, size := range []*utility.Size{{Width: 13, Height: 5}, {Width: 4, Height: 9}} {
+		known := utility.NewBooleanMatrix(size)
+		for x := 0; x < size.Width; x++ {
+			for y := 0; y < size.Height; y++ {
+				known.Matrix[x][y] = (x*7+y*3)%4 == 0
+			}
+		}
+		if err := s.SaveKnownBlocks(character.ID, known); err != nil {
+			t.Fatalf("SaveKnownBlocks() error = %v", err)
+		}
+
+		loaded, err := s.LoadKnownBlocks(character.ID)
+		if err != nil {
+			t.Fatalf("LoadKnownBlocks() error = %v", err)
+		}
+		if *loaded.Size != *size {
+			t.Fatalf("LoadKnownBlocks() size = %v, want %v", loaded.Size, size)
+		}
+		for x := 0; x < size.Width; x++ {
+			for y := 0; y < size.Height; y++ {
+				if loaded.Matrix[x][y] != known.Matrix[x][y] {
+					t.Errorf("LoadKnownBlocks() block %v,%v = %v, want %v", x, y, loaded.Matrix[x][y], known.Matrix[x][y])
+				}
+			}
+		}
+	}
+}
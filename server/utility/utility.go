@@ -77,6 +77,16 @@ func NewBooleanMatrix(size *Size) *BooleanMatrix {
 	}
 }
 
+// Copy returns a deep copy of the matrix
+func (m *BooleanMatrix) Copy() *BooleanMatrix {
+	copied := NewBooleanMatrix(&Size{Width: m.Size.Width, Height: m.Size.Height})
+	for x := range m.Matrix {
+		copy(copied.Matrix[x], m.Matrix[x])
+	}
+
+	return copied
+}
+
 func (m *BooleanMatrix) String() string {
 	return fmt.Sprintf("<BooleanMatrix>[%v, %v]", m.Size, m.Matrix)
 }
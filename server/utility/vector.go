@@ -1 +1,70 @@
 package utility
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Vector is a direction and magnitude in a 2D coordinate system, using float64s
+type Vector struct {
+	X, Y float64
+}
+
+// NewVectorFromDirection creates a unit vector from a named direction.
+// The direction is made up of "up", "down", "left" and "right" in any case and combination (e.g. "UpLeft").
+// Unrecognized or opposing directions result in a zero vector.
+func NewVectorFromDirection(direction string) *Vector {
+	direction = strings.ToLower(direction)
+	v := &Vector{}
+
+	if strings.Contains(direction, "up") {
+		v.Y--
+	}
+	if strings.Contains(direction, "down") {
+		v.Y++
+	}
+	if strings.Contains(direction, "left") {
+		v.X--
+	}
+	if strings.Contains(direction, "right") {
+		v.X++
+	}
+
+	return v.Normalize()
+}
+
+func (v *Vector) String() string {
+	return fmt.Sprintf("<Vector>[x: %v, y: %v]", v.X, v.Y)
+}
+
+// Length returns the magnitude of the vector
+func (v *Vector) Length() float64 {
+	return math.Hypot(v.X, v.Y)
+}
+
+// IsZero returns true if the vector has no magnitude
+func (v *Vector) IsZero() bool {
+	return v.X == 0 && v.Y == 0
+}
+
+// Normalize returns a new vector with the same direction and a magnitude of one.
+// A zero vector stays a zero vector.
+func (v *Vector) Normalize() *Vector {
+	length := v.Length()
+	if length == 0 {
+		return &Vector{}
+	}
+
+	return &Vector{X: v.X / length, Y: v.Y / length}
+}
+
+// Scale returns a new vector with the magnitude multiplied by a factor
+func (v *Vector) Scale(factor float64) *Vector {
+	return &Vector{X: v.X * factor, Y: v.Y * factor}
+}
+
+// Angle returns the direction of the vector in radians
+func (v *Vector) Angle() float64 {
+	return math.Atan2(v.Y, v.X)
+}